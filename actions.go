@@ -2,6 +2,7 @@ package chromedp
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
@@ -28,10 +29,7 @@ type Tasks []Action
 // Do executes the list of Actions sequentially, using the provided context and
 // frame handler.
 func (t Tasks) Do(ctxt context.Context, h cdp.Executor) error {
-	// TODO: put individual task timeouts from context here
 	for _, a := range t {
-		// ctxt, cancel = context.WithTimeout(ctxt, timeout)
-		// defer cancel()
 		if err := a.Do(ctxt, h); err != nil {
 			return err
 		}
@@ -40,6 +38,44 @@ func (t Tasks) Do(ctxt context.Context, h cdp.Executor) error {
 	return nil
 }
 
+// TimeoutError is the error returned by Timeout when its deadline expires
+// before the wrapped action completes.
+type TimeoutError struct {
+	// Timeout is the deadline given to Timeout.
+	Timeout time.Duration
+
+	// Err is the error returned by the wrapped action.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout after %v: %v", err.Timeout, err.Err)
+}
+
+// Unwrap returns the error returned by the wrapped action.
+func (err *TimeoutError) Unwrap() error {
+	return err.Err
+}
+
+// Timeout is an action that executes the action a with a deadline of d. When
+// the deadline expires before a completes, a TimeoutError wrapping the error
+// returned by a is returned.
+func Timeout(d time.Duration, a Action) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		c, cancel := context.WithTimeout(ctxt, d)
+		defer cancel()
+
+		err := a.Do(c, h)
+		// only report the timeout when it was this deadline that fired, and
+		// not one of the parent context's
+		if err != nil && c.Err() == context.DeadlineExceeded && ctxt.Err() == nil {
+			return &TimeoutError{Timeout: d, Err: err}
+		}
+		return err
+	})
+}
+
 // Sleep is an empty action that calls time.Sleep with the specified duration.
 //
 // Note: this is a temporary action definition for convenience, and will likely
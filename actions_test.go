@@ -0,0 +1,57 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	block := ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		<-ctxt.Done()
+		return ctxt.Err()
+	})
+
+	err := Timeout(50*time.Millisecond, block).Do(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "timeout after 50ms") {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected wrapped %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	// the inner error is preserved
+	query := ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		<-ctxt.Done()
+		return &QueryError{Selector: "#missing", Err: ErrNoResults}
+	})
+	err = Timeout(50*time.Millisecond, query).Do(context.Background(), nil)
+	var qerr *QueryError
+	if !errors.As(err, &qerr) || !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected wrapped query error, got: %v", err)
+	}
+
+	// a parent deadline is not reported as this timeout
+	ctxt, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Timeout(time.Second, block).Do(ctxt, nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	ctxt, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err = Timeout(time.Second, block).Do(ctxt, nil); err != context.Canceled {
+		t.Errorf("expected %v, got: %v", context.Canceled, err)
+	}
+
+	if err = Timeout(time.Second, Tasks{}).Do(context.Background(), nil); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
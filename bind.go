@@ -0,0 +1,217 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// BindFunc is a Go func exposed to page javascript by Bind, passed the
+// JSON-encoded payload of the javascript call. The returned value is
+// JSON-encoded and resolves the call's promise, while a returned error rejects
+// the call's promise.
+type BindFunc func(ctxt context.Context, payload json.RawMessage) (interface{}, error)
+
+// binding is a Go func exposed to page javascript.
+type binding struct {
+	f        BindFunc
+	scriptID page.ScriptIdentifier
+}
+
+// Bind exposes f to the target's page javascript as the global func name,
+// taking a single JSON-serializable payload and returning a promise resolved
+// with f's result. The func is available in the current document and in all
+// documents subsequently loaded by the target.
+//
+// For example, with a bound "secret" func, a page script can call:
+//
+//	const token = await window.secret({name: 'api-token'});
+//
+// The returned func removes the binding from subsequently loaded documents,
+// and rejects the pending and subsequent calls in the current document. A
+// panic in f rejects the call's promise.
+func (h *TargetHandler) Bind(ctxt context.Context, name string, f BindFunc) (func(context.Context) error, error) {
+	h.bmu.Lock()
+	defer h.bmu.Unlock()
+
+	h.brw.Lock()
+	if b, ok := h.bindings[name]; ok {
+		b.f = f
+		h.brw.Unlock()
+		return h.unbind(name), nil
+	}
+	// add the binding before exposing it, so that its first calls are not
+	// dropped
+	b := &binding{f: f}
+	h.bindings[name] = b
+	h.brw.Unlock()
+
+	id, err := h.addBinding(ctxt, name)
+	if err != nil {
+		h.brw.Lock()
+		delete(h.bindings, name)
+		h.brw.Unlock()
+		return nil, err
+	}
+
+	h.brw.Lock()
+	b.scriptID = id
+	h.brw.Unlock()
+
+	return h.unbind(name), nil
+}
+
+// addBinding exposes the named binding to the target's page javascript,
+// returning the id of the script wrapping the binding in new documents.
+func (h *TargetHandler) addBinding(ctxt context.Context, name string) (page.ScriptIdentifier, error) {
+	buf, err := json.Marshal(name)
+	if err != nil {
+		return "", err
+	}
+	script := fmt.Sprintf(bindingJS, buf)
+
+	if err := runtime.AddBinding(name).Do(ctxt, h); err != nil {
+		return "", err
+	}
+
+	id, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctxt, h)
+	if err != nil {
+		runtime.RemoveBinding(name).Do(ctxt, h)
+		return "", err
+	}
+
+	// wrap the binding in the current document
+	_, exp, err := runtime.Evaluate(script).Do(ctxt, h)
+	if err == nil && exp != nil {
+		err = exp
+	}
+	if err != nil {
+		page.RemoveScriptToEvaluateOnNewDocument(id).Do(ctxt, h)
+		runtime.RemoveBinding(name).Do(ctxt, h)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// unbind returns a func that removes the named binding.
+func (h *TargetHandler) unbind(name string) func(context.Context) error {
+	return func(ctxt context.Context) error {
+		h.bmu.Lock()
+		defer h.bmu.Unlock()
+
+		h.brw.RLock()
+		b, ok := h.bindings[name]
+		var id page.ScriptIdentifier
+		if ok {
+			id = b.scriptID
+		}
+		h.brw.RUnlock()
+
+		if !ok {
+			return nil
+		}
+
+		if err := page.RemoveScriptToEvaluateOnNewDocument(id).Do(ctxt, h); err != nil {
+			return err
+		}
+		if err := runtime.RemoveBinding(name).Do(ctxt, h); err != nil {
+			return err
+		}
+
+		// only forget the binding once removed, so a failed unbind can be
+		// retried
+		h.brw.Lock()
+		delete(h.bindings, name)
+		h.brw.Unlock()
+
+		// reject the calls in the current document, which are no longer
+		// delivered
+		buf, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		_, exp, err := runtime.Evaluate(fmt.Sprintf(unbindJS, buf)).Do(ctxt, h)
+		if err == nil && exp != nil {
+			err = exp
+		}
+		return err
+	}
+}
+
+// bindingCalled calls the binding's Go func, delivering its result to the
+// calling page javascript.
+func (h *TargetHandler) bindingCalled(ctxt context.Context, ev *runtime.EventBindingCalled) {
+	h.brw.RLock()
+	b, ok := h.bindings[ev.Name]
+	var f BindFunc
+	if ok {
+		f = b.f
+	}
+	h.brw.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	var call struct {
+		ID      int64           `json:"id"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(ev.Payload), &call); err != nil {
+		h.errf("could not decode binding %s payload: %v", ev.Name, err)
+		return
+	}
+
+	var errText interface{}
+	res, err := callBinding(ctxt, f, call.Payload)
+	if err != nil {
+		res, errText = nil, err.Error()
+	}
+
+	args, err := callArguments(ev.Name, call.ID, res, errText)
+	if err != nil {
+		args, _ = callArguments(ev.Name, call.ID, nil, err.Error())
+	}
+
+	_, exp, err := runtime.CallFunctionOn(deliverBindingJS).
+		WithExecutionContextID(ev.ExecutionContextID).
+		WithArguments(args).
+		Do(ctxt, h)
+	switch {
+	case err != nil:
+		h.errf("could not deliver binding %s result: %v", ev.Name, err)
+	case exp != nil:
+		h.errf("could not deliver binding %s result: %v", ev.Name, exp)
+	}
+}
+
+// callBinding calls f with the payload, returning a panic in f as an error,
+// as f is called on its own goroutine.
+func callBinding(ctxt context.Context, f BindFunc, payload json.RawMessage) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("binding panicked: %v", r)
+		}
+	}()
+
+	return f(ctxt, payload)
+}
+
+// Bind is an action that exposes f to the current target's page javascript
+// as the global func name. See TargetHandler.Bind.
+func Bind(name string, f BindFunc) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		_, err := th.Bind(ctxt, name, f)
+		return err
+	})
+}
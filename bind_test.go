@@ -0,0 +1,148 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	add := func(ctxt context.Context, payload json.RawMessage) (interface{}, error) {
+		var v struct {
+			A, B int
+		}
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		if v.B == 0 {
+			return nil, errors.New("b cannot be zero")
+		}
+		return v.A + v.B, nil
+	}
+
+	var sum int
+	err := c.Run(defaultContext, Tasks{
+		Bind("add", add),
+		Evaluate(`window.add({A: 1, B: 2})`, &sum, EvalAwaitPromise),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if sum != 3 {
+		t.Errorf("expected 3, got: %d", sum)
+	}
+
+	var msg string
+	err = c.Run(defaultContext, Evaluate(`window.add({A: 1}).catch(function(err) { return err.message; })`, &msg, EvalAwaitPromise))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if msg != "b cannot be zero" {
+		t.Errorf("expected rejection, got: %q", msg)
+	}
+
+	// the binding is available after navigating
+	err = c.Run(defaultContext, Tasks{
+		Navigate(testdataDir + "/form.html"),
+		Evaluate(`window.add({A: 2, B: 3})`, &sum, EvalAwaitPromise),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if sum != 5 {
+		t.Errorf("expected 5, got: %d", sum)
+	}
+
+	// the binding is removed from subsequently loaded documents
+	unbind, err := c.CDP().Bind(defaultContext, "add", add)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if err = unbind(defaultContext); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	var typ string
+	err = c.Run(defaultContext, Tasks{
+		Navigate(testdataDir + "/form.html"),
+		Evaluate(`typeof window.add`, &typ),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if typ != "undefined" {
+		t.Errorf("expected undefined, got: %q", typ)
+	}
+}
+
+func TestBindUnbindPending(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	called, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	wait := func(ctxt context.Context, payload json.RawMessage) (interface{}, error) {
+		close(called)
+		select {
+		case <-release:
+		case <-ctxt.Done():
+		}
+		return nil, nil
+	}
+
+	unbind, err := c.CDP().Bind(defaultContext, "wait", wait)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	var ok bool
+	err = c.Run(defaultContext, Evaluate(`window.pending = window.wait().catch(function(err) { return err.message; }), true`, &ok))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected binding to be called")
+	}
+
+	// the pending call and subsequent calls are rejected
+	if err = unbind(defaultContext); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	var pending, next string
+	err = c.Run(defaultContext, Tasks{
+		Evaluate(`window.pending`, &pending, EvalAwaitPromise),
+		Evaluate(`window.wait().catch(function(err) { return err.message; })`, &next, EvalAwaitPromise),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if exp := "binding wait removed"; pending != exp || next != exp {
+		t.Errorf("expected rejections %q, got: %q and %q", exp, pending, next)
+	}
+}
+
+func TestCallBindingPanic(t *testing.T) {
+	t.Parallel()
+
+	f := func(ctxt context.Context, payload json.RawMessage) (interface{}, error) {
+		panic("boom")
+	}
+
+	res, err := callBinding(context.Background(), f, nil)
+	if res != nil {
+		t.Errorf("expected no result, got: %v", res)
+	}
+	if err == nil || err.Error() != "binding panicked: boom" {
+		t.Errorf("expected panic error, got: %v", err)
+	}
+}
@@ -0,0 +1,459 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/target"
+
+	"github.com/chromedp/chromedp/client"
+)
+
+// Browser is a browser-level Chrome DevTools Protocol connection, used for
+// target discovery and management.
+type Browser struct {
+	conn client.Transport
+
+	// urlstr is the browser's websocket URL.
+	urlstr string
+
+	// events is the incoming event queue.
+	events chan interface{}
+
+	// done is closed when the connection has been closed.
+	done chan struct{}
+
+	// last is the last sent message identifier.
+	last  int64
+	lastm sync.Mutex
+
+	// res is the id->result channel map.
+	res   map[int64]chan *cdproto.Message
+	resrw sync.RWMutex
+
+	// mux is the multiplexer of attached flattened sessions.
+	mux *sessionMux
+
+	// wm serializes writes to the connection.
+	wm sync.Mutex
+
+	// logging funcs
+	logf, debugf, errf func(string, ...interface{})
+}
+
+// NewBrowser creates a new browser-level connection to the specified browser
+// websocket URL (ie, the webSocketDebuggerUrl reported by /json/version).
+//
+// Callers can stop the connection by closing the passed context.
+func NewBrowser(ctxt context.Context, urlstr string, logf, debugf, errf func(string, ...interface{})) (*Browser, error) {
+	conn, err := client.Dial(urlstr)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Browser{
+		conn:   conn,
+		urlstr: urlstr,
+		events: make(chan interface{}, 1024),
+		done:   make(chan struct{}),
+		res:    make(map[int64]chan *cdproto.Message),
+		logf:   logf,
+		debugf: debugf,
+		errf:   errf,
+	}
+	b.mux = newSessionMux(b.write, b.detach)
+
+	go b.run(ctxt)
+
+	return b, nil
+}
+
+// browserMessage is a message read from the browser connection, or from a
+// target connection, which may be tagged with the flattened session it belongs
+// to.
+type browserMessage struct {
+	SessionID target.SessionID    `json:"sessionId,omitempty"`
+	ID        int64               `json:"id,omitempty"`
+	Method    cdproto.MethodType  `json:"method,omitempty"`
+	Params    easyjson.RawMessage `json:"params,omitempty"`
+	Result    easyjson.RawMessage `json:"result,omitempty"`
+	Error     *cdproto.Error      `json:"error,omitempty"`
+}
+
+// message returns the message as a cdproto.Message.
+func (m *browserMessage) message() *cdproto.Message {
+	return &cdproto.Message{
+		ID:     m.ID,
+		Method: m.Method,
+		Params: m.Params,
+		Result: m.Result,
+		Error:  m.Error,
+	}
+}
+
+// run reads incoming messages from the browser connection, dispatching
+// command results, events, and session messages.
+func (b *Browser) run(ctxt context.Context) {
+	defer close(b.events)
+	defer close(b.done)
+	defer b.mux.closeSessions()
+
+	go func() {
+		<-ctxt.Done()
+		b.conn.Close()
+	}()
+
+	for {
+		buf, err := b.conn.Read()
+		if err != nil {
+			return
+		}
+
+		b.debugf("-> %s", string(buf))
+
+		m := new(browserMessage)
+		if err = json.Unmarshal(buf, m); err != nil {
+			continue
+		}
+
+		// route session messages to the session's transport
+		if m.SessionID != "" {
+			b.mux.route(m.SessionID, buf)
+			continue
+		}
+
+		msg := m.message()
+
+		switch {
+		case msg.Method != "":
+			ev, err := cdproto.UnmarshalMessage(msg)
+			if err != nil {
+				continue
+			}
+
+			if e, ok := ev.(*target.EventDetachedFromTarget); ok {
+				b.mux.removeSession(e.SessionID)
+			}
+
+			select {
+			case b.events <- ev:
+			case <-ctxt.Done():
+				return
+			}
+
+		case msg.ID != 0:
+			b.resrw.RLock()
+			ch, ok := b.res[msg.ID]
+			b.resrw.RUnlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+}
+
+// Events returns the channel of decoded browser events. The channel is closed
+// when the connection is closed.
+func (b *Browser) Events() <-chan interface{} {
+	return b.events
+}
+
+// Execute executes commandType against the browser connection, using the
+// provided context and params, decoding the result of the command to res.
+func (b *Browser) Execute(ctxt context.Context, methodType string, params json.Marshaler, res json.Unmarshaler) error {
+	paramsBuf := emptyObj
+	if params != nil {
+		var err error
+		paramsBuf, err = json.Marshal(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	id := b.next()
+
+	// save channel
+	ch := make(chan *cdproto.Message, 1)
+	b.resrw.Lock()
+	b.res[id] = ch
+	b.resrw.Unlock()
+
+	defer func() {
+		b.resrw.Lock()
+		defer b.resrw.Unlock()
+		delete(b.res, id)
+	}()
+
+	// marshal
+	buf, err := json.Marshal(&cdproto.Message{
+		ID:     id,
+		Method: cdproto.MethodType(methodType),
+		Params: paramsBuf,
+	})
+	if err != nil {
+		return err
+	}
+
+	b.debugf("<- %s", string(buf))
+
+	if err = b.write(buf); err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-ch:
+		switch {
+		case msg.Error != nil:
+			return msg.Error
+
+		case res != nil:
+			return json.Unmarshal(msg.Result, res)
+		}
+
+	case <-b.done:
+		return ErrChannelClosed
+
+	case <-ctxt.Done():
+		return ctxt.Err()
+	}
+
+	return nil
+}
+
+// write writes a message to the browser connection.
+func (b *Browser) write(buf []byte) error {
+	b.wm.Lock()
+	defer b.wm.Unlock()
+
+	return b.conn.Write(buf)
+}
+
+// Attach attaches to the target with the specified id using a flattened
+// session, returning a transport that sends and receives the session's
+// messages over the browser connection.
+func (b *Browser) Attach(ctxt context.Context, id target.ID) (client.Transport, error) {
+	sessionID, err := target.AttachToTarget(id).WithFlatten(true).Do(ctxt, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.mux.addSession(sessionID), nil
+}
+
+// detach detaches the session with the specified id.
+func (b *Browser) detach(id target.SessionID) {
+	ctxt, cancel := context.WithTimeout(context.Background(), DefaultNewTargetTimeout)
+	defer cancel()
+
+	target.DetachFromTarget().WithSessionID(id).Do(ctxt, b)
+}
+
+// next returns the next message id.
+func (b *Browser) next() int64 {
+	b.lastm.Lock()
+	defer b.lastm.Unlock()
+	b.last++
+	return b.last
+}
+
+// pageTarget builds a client target for the target info, using the page
+// websocket URL derived from the browser's websocket URL.
+func (b *Browser) pageTarget(info *target.Info) *client.Chrome {
+	urlstr := b.urlstr
+	if i := strings.Index(urlstr, "/devtools/browser/"); i != -1 {
+		urlstr = urlstr[:i]
+	}
+
+	return &client.Chrome{
+		ID:           string(info.TargetID),
+		Title:        info.Title,
+		Type:         client.TargetType(info.Type),
+		URL:          info.URL,
+		WebsocketURL: urlstr + "/devtools/page/" + string(info.TargetID),
+	}
+}
+
+// sessionMux multiplexes flattened sessions over a single connection (ie, the
+// browser connection, or the connection of a target with out-of-process
+// iframes).
+type sessionMux struct {
+	// write writes a message to the underlying connection.
+	write func([]byte) error
+
+	// detach, when not nil, is called when a session is closed by its
+	// user.
+	detach func(target.SessionID)
+
+	// sessions is the map of attached flattened sessions.
+	sessions   map[target.SessionID]*session
+	sessionsrw sync.RWMutex
+}
+
+// newSessionMux creates a session multiplexer writing to the underlying
+// connection with write.
+func newSessionMux(write func([]byte) error, detach func(target.SessionID)) *sessionMux {
+	return &sessionMux{
+		write:    write,
+		detach:   detach,
+		sessions: make(map[target.SessionID]*session),
+	}
+}
+
+// addSession adds a session with the specified id, returning its transport.
+func (m *sessionMux) addSession(id target.SessionID) *session {
+	s := &session{
+		m:     m,
+		id:    id,
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	m.sessionsrw.Lock()
+	m.sessions[id] = s
+	m.sessionsrw.Unlock()
+
+	return s
+}
+
+// route delivers an incoming message to the session with the specified id.
+func (m *sessionMux) route(id target.SessionID, buf []byte) {
+	m.sessionsrw.RLock()
+	s, ok := m.sessions[id]
+	m.sessionsrw.RUnlock()
+
+	if ok {
+		s.deliver(buf)
+	}
+}
+
+// removeSession removes and closes the session with the specified id.
+func (m *sessionMux) removeSession(id target.SessionID) {
+	m.sessionsrw.Lock()
+	s, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.sessionsrw.Unlock()
+
+	if ok {
+		s.close()
+	}
+}
+
+// closeSessions closes all attached sessions.
+func (m *sessionMux) closeSessions() {
+	m.sessionsrw.Lock()
+	defer m.sessionsrw.Unlock()
+
+	for id, s := range m.sessions {
+		s.close()
+		delete(m.sessions, id)
+	}
+}
+
+// session is a flattened target session multiplexed over a connection,
+// satisfying the client.Transport interface.
+type session struct {
+	m  *sessionMux
+	id target.SessionID
+
+	// msgs is the incoming message queue, which is unbounded so that a
+	// stalled session never blocks the shared connection's read loop.
+	msgs  [][]byte
+	msgsm sync.Mutex
+
+	// ready is signalled when a message has been queued.
+	ready chan struct{}
+
+	// done is closed when the session is closed.
+	done chan struct{}
+	once sync.Once
+}
+
+// deliver queues an incoming message for the session.
+func (s *session) deliver(buf []byte) {
+	s.msgsm.Lock()
+	s.msgs = append(s.msgs, buf)
+	s.msgsm.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Read reads the next message for the session.
+func (s *session) Read() ([]byte, error) {
+	for {
+		s.msgsm.Lock()
+		if len(s.msgs) != 0 {
+			buf := s.msgs[0]
+			s.msgs[0] = nil
+			s.msgs = s.msgs[1:]
+			s.msgsm.Unlock()
+			return buf, nil
+		}
+		s.msgsm.Unlock()
+
+		select {
+		case <-s.ready:
+		case <-s.done:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Write writes a message for the session, tagging it with the session id.
+func (s *session) Write(buf []byte) error {
+	select {
+	case <-s.done:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	if len(buf) < 2 || buf[0] != '{' {
+		return ErrInvalidMessage
+	}
+
+	tag, err := json.Marshal(s.id)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, 0, len(buf)+len(tag)+14)
+	msg = append(msg, `{"sessionId":`...)
+	msg = append(msg, tag...)
+	if buf[1] != '}' {
+		msg = append(msg, ',')
+	}
+	msg = append(msg, buf[1:]...)
+
+	return s.m.write(msg)
+}
+
+// Close closes the session, detaching from its target.
+func (s *session) Close() error {
+	s.m.sessionsrw.Lock()
+	_, ok := s.m.sessions[s.id]
+	delete(s.m.sessions, s.id)
+	s.m.sessionsrw.Unlock()
+
+	s.close()
+
+	if ok && s.m.detach != nil {
+		go s.m.detach(s.id)
+	}
+
+	return nil
+}
+
+// close marks the session as closed.
+func (s *session) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
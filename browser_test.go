@@ -0,0 +1,109 @@
+package chromedp
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/target"
+)
+
+// testTransport is a client.Transport that records written messages.
+type testTransport struct {
+	written [][]byte
+}
+
+func (t *testTransport) Read() ([]byte, error) {
+	select {}
+}
+
+func (t *testTransport) Write(buf []byte) error {
+	t.written = append(t.written, buf)
+	return nil
+}
+
+func (t *testTransport) Close() error {
+	return nil
+}
+
+func TestSessionWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg, exp string
+	}{
+		{`{"id":1,"method":"Page.enable","params":{}}`, `{"sessionId":"S1","id":1,"method":"Page.enable","params":{}}`},
+		{`{}`, `{"sessionId":"S1"}`},
+	}
+
+	for i, test := range tests {
+		conn := new(testTransport)
+		s := newSessionMux(conn.Write, nil).addSession("S1")
+
+		if err := s.Write([]byte(test.msg)); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if len(conn.written) != 1 {
+			t.Fatalf("test %d expected 1 written message, got: %d", i, len(conn.written))
+		}
+		if got := string(conn.written[0]); got != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, got)
+		}
+	}
+}
+
+func TestSessionDeliver(t *testing.T) {
+	t.Parallel()
+
+	m := newSessionMux(new(testTransport).Write, nil)
+	s := m.addSession("S1")
+
+	// a session that is not read from does not block routing
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2048; i++ {
+			m.route("S1", []byte(fmt.Sprintf(`{"id":%d}`, i)))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected routing to not block")
+	}
+
+	for i := 0; i < 2048; i++ {
+		buf, err := s.Read()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if exp := fmt.Sprintf(`{"id":%d}`, i); string(buf) != exp {
+			t.Fatalf("expected %s, got: %s", exp, buf)
+		}
+	}
+
+	m.removeSession("S1")
+	if _, err := s.Read(); err != io.EOF {
+		t.Errorf("expected %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestBrowserPageTarget(t *testing.T) {
+	t.Parallel()
+
+	b := &Browser{urlstr: "ws://127.0.0.1:9222/devtools/browser/abc"}
+	pt := b.pageTarget(&target.Info{
+		TargetID: "T1",
+		Type:     "page",
+		Title:    "title",
+		URL:      "about:blank",
+	})
+
+	if exp := "ws://127.0.0.1:9222/devtools/page/T1"; pt.GetWebsocketURL() != exp {
+		t.Errorf("expected %s, got: %s", exp, pt.GetWebsocketURL())
+	}
+	if pt.GetID() != "T1" {
+		t.Errorf("expected id T1, got: %s", pt.GetID())
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/target"
 
 	"github.com/chromedp/chromedp/client"
 	"github.com/chromedp/chromedp/runner"
@@ -25,9 +27,22 @@ const (
 	// be started.
 	DefaultNewTargetTimeout = 3 * time.Second
 
+	// DefaultQueryTimeout is the default time to wait for a query's selector
+	// to match, and for the query's conditions to be met.
+	DefaultQueryTimeout = 100 * time.Second
+
+	// DefaultWaitTimeout is the default time to wait for a frame or node to be
+	// loaded, for a javascript dialog to open, for a target opened by the
+	// current target to be started, and for the network to be idle.
+	DefaultWaitTimeout = 10 * time.Second
+
 	// DefaultCheckDuration is the default time to sleep between a check.
 	DefaultCheckDuration = 50 * time.Millisecond
 
+	// DefaultListenerQueueSize is the default number of events queued for a
+	// target event listener before events are dropped.
+	DefaultListenerQueueSize = 1024
+
 	// DefaultPoolStartPort is the default start port number.
 	DefaultPoolStartPort = 9000
 
@@ -45,7 +60,19 @@ type CDP struct {
 	// opts are command line options to pass to a created runner.
 	opts []runner.CommandLineOption
 
-	// watch is the channel for new client targets.
+	// cl is the client used for target management when no runner was
+	// provided.
+	cl *client.Client
+
+	// b is the browser connection used for target discovery.
+	b *Browser
+
+	// flatten toggles attaching to targets using flattened sessions over the
+	// browser connection.
+	flatten bool
+
+	// watch is the channel for new client targets, when not discovering
+	// targets over the browser connection.
 	watch <-chan client.Target
 
 	// cur is the current active target's handler.
@@ -57,20 +84,53 @@ type CDP struct {
 	// handlerMap is the map of target IDs to its active handler.
 	handlerMap map[string]int
 
+	// contexts is the map of target IDs to the browser context created for
+	// the target.
+	contexts map[string]target.BrowserContextID
+
+	// opened is the map of opener target IDs to the IDs of the page targets
+	// they opened that have not yet been returned by WaitNewTarget.
+	opened map[string][]string
+
+	// update is closed and replaced whenever the active handlers change.
+	update chan struct{}
+
 	// logging funcs
 	logf, debugf, errf func(string, ...interface{})
 
+	// consolef receives the console messages of all targets.
+	consolef func(string, ...interface{})
+
+	// console are the console message listeners.
+	console []func(*ConsoleMessage)
+
+	// dialogf is the func used to automatically handle javascript dialogs.
+	dialogf DialogFunc
+
+	// queryTimeout and waitTimeout are the handlers' default query and
+	// frame/node wait timeouts.
+	queryTimeout, waitTimeout time.Duration
+
+	// queryDiagnostics toggles capturing a screenshot and the document's
+	// outer HTML when a query times out.
+	queryDiagnostics bool
+
 	sync.RWMutex
 }
 
 // New creates and starts a new CDP instance.
 func New(ctxt context.Context, opts ...Option) (*CDP, error) {
 	c := &CDP{
-		handlers:   make([]*TargetHandler, 0),
-		handlerMap: make(map[string]int),
-		logf:       log.Printf,
-		debugf:     func(string, ...interface{}) {},
-		errf:       func(s string, v ...interface{}) { log.Printf("error: "+s, v...) },
+		handlers:     make([]*TargetHandler, 0),
+		handlerMap:   make(map[string]int),
+		contexts:     make(map[string]target.BrowserContextID),
+		opened:       make(map[string][]string),
+		update:       make(chan struct{}),
+		queryTimeout: DefaultQueryTimeout,
+		waitTimeout:  DefaultWaitTimeout,
+		logf:         log.Printf,
+		debugf:       func(string, ...interface{}) {},
+		errf:         func(s string, v ...interface{}) { log.Printf("error: "+s, v...) },
 	}
 
 	// apply options
@@ -81,7 +141,7 @@ func New(ctxt context.Context, opts ...Option) (*CDP, error) {
 	}
 
 	// check for supplied runner, if none then create one
-	if c.r == nil && c.watch == nil {
+	if c.r == nil && c.cl == nil && c.watch == nil {
 		var err error
 		c.r, err = runner.Run(ctxt, c.opts...)
 		if err != nil {
@@ -89,42 +149,226 @@ func New(ctxt context.Context, opts ...Option) (*CDP, error) {
 		}
 	}
 
-	// watch handlers
+	// watch handlers, preferring target discovery over the browser
+	// connection, and falling back to polling the client
 	if c.watch == nil {
-		c.watch = c.r.Client().WatchPageTargets(ctxt)
-	}
+		if err := c.connectBrowser(ctxt); err != nil {
+			c.debugf("could not connect to browser, polling for targets: %v", err)
 
-	go func() {
-		for t := range c.watch {
-			if t == nil {
-				return
+			cl, err := c.client()
+			if err != nil {
+				return nil, err
 			}
-			go c.AddTarget(ctxt, t)
+			c.watch = cl.WatchPageTargets(ctxt)
 		}
-	}()
+	}
 
-	// TODO: fix this
-	timeout := time.After(defaultNewTargetTimeout)
+	if c.watch != nil {
+		go func() {
+			for t := range c.watch {
+				if t == nil {
+					return
+				}
+				go c.AddTarget(ctxt, t)
+			}
+		}()
+	}
 
 	// wait until at least one target active
+	err := c.waitUntil(ctxt, defaultNewTargetTimeout, "timeout waiting for initial target", func() bool {
+		return c.cur != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// connectBrowser connects to the browser target, and enables target discovery
+// for the browser's page targets.
+func (c *CDP) connectBrowser(ctxt context.Context) error {
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	// the browser may still be starting, so retry until its version
+	// information is available
+	var urlstr string
+	timeout := time.After(defaultNewTargetTimeout)
 	for {
+		urlstr, err = cl.BrowserWebsocketURL(ctxt)
+		if err == nil || err == client.ErrMissingBrowserWebsocketURL {
+			break
+		}
+
 		select {
-		default:
+		case <-time.After(DefaultCheckDuration):
+
+		case <-ctxt.Done():
+			return ctxt.Err()
+
+		case <-timeout:
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	b, err := NewBrowser(ctxt, urlstr, c.logf, c.debugf, c.errf)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	c.b = b
+	c.Unlock()
+
+	go c.watchBrowser(ctxt, b)
+
+	if err = target.SetDiscoverTargets(true).Do(ctxt, b); err != nil {
+		c.Lock()
+		c.b = nil
+		c.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// watchBrowser handles the target events of the browser connection, adding
+// and removing handlers as page targets are created and destroyed.
+func (c *CDP) watchBrowser(ctxt context.Context, b *Browser) {
+	for ev := range b.Events() {
+		switch e := ev.(type) {
+		case *target.EventTargetCreated:
+			if e.TargetInfo.Type != client.Page.String() {
+				continue
+			}
+			if opener := string(e.TargetInfo.OpenerID); opener != "" {
+				c.Lock()
+				c.opened[opener] = append(c.opened[opener], string(e.TargetInfo.TargetID))
+				c.Unlock()
+			}
+			go c.AddTarget(ctxt, b.pageTarget(e.TargetInfo))
+
+		case *target.EventTargetInfoChanged:
+			c.targetInfoChanged(e.TargetInfo)
+
+		case *target.EventTargetDestroyed:
 			c.RLock()
-			exists := c.cur != nil
+			i, ok := c.handlerMap[string(e.TargetID)]
+			var h *TargetHandler
+			if ok {
+				h = c.handlers[i]
+			}
 			c.RUnlock()
-			if exists {
-				return c, nil
+
+			if h != nil {
+				c.removeHandler(h)
 			}
+			c.removeOpened(string(e.TargetID))
 
-			// TODO: fix this
-			time.Sleep(DefaultCheckDuration)
+			go c.disposeContext(ctxt, b, string(e.TargetID))
+		}
+	}
+}
+
+// targetInfoChanged updates the client target of the handler associated with
+// the target info.
+func (c *CDP) targetInfoChanged(info *target.Info) {
+	c.Lock()
+	defer c.Unlock()
+
+	i, ok := c.handlerMap[string(info.TargetID)]
+	if !ok {
+		return
+	}
+
+	if t, ok := c.handlers[i].Target().(*client.Chrome); ok {
+		t.Title, t.URL = info.Title, info.URL
+	}
+}
+
+// removeOpened removes the target with the specified id from the opened
+// targets, both as an opener and as an opened target.
+func (c *CDP) removeOpened(id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.opened, id)
+	for opener, ids := range c.opened {
+		for i, x := range ids {
+			if x == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(c.opened, opener)
+		} else {
+			c.opened[opener] = ids
+		}
+	}
+}
+
+// disposeContext disposes the browser context created for the target with the
+// specified id, if any.
+func (c *CDP) disposeContext(ctxt context.Context, b *Browser, id string) {
+	c.Lock()
+	contextID, ok := c.contexts[id]
+	delete(c.contexts, id)
+	c.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if err := target.DisposeBrowserContext(contextID).Do(ctxt, b); err != nil {
+		c.errf("could not dispose browser context %s: %v", contextID, err)
+	}
+}
+
+// notify signals waiters that the active handlers have changed.
+//
+// Note: must be called with the lock held.
+func (c *CDP) notify() {
+	close(c.update)
+	c.update = make(chan struct{})
+}
+
+// waitUntil waits until cond returns true, re-checking cond each time the
+// active handlers change. Returns an error with the supplied message if
+// timeout elapses before cond is met. A timeout of 0 disables the timeout.
+//
+// Note: cond is called with the read lock held.
+func (c *CDP) waitUntil(ctxt context.Context, timeout time.Duration, msg string, cond func() bool) error {
+	var expired <-chan time.Time
+	if timeout != 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	for {
+		c.RLock()
+		ok, update := cond(), c.update
+		c.RUnlock()
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-update:
 
 		case <-ctxt.Done():
-			return nil, ctxt.Err()
+			return ctxt.Err()
 
-		case <-timeout:
-			return nil, errors.New("timeout waiting for initial target")
+		case <-expired:
+			return errors.New(msg)
 		}
 	}
 }
@@ -134,15 +378,31 @@ func (c *CDP) AddTarget(ctxt context.Context, t client.Target) {
 	c.Lock()
 	defer c.Unlock()
 
+	if _, ok := c.handlerMap[t.GetID()]; ok {
+		return
+	}
+
 	// create target manager
-	h, err := NewTargetHandler(t, c.logf, c.debugf, c.errf)
+	h, err := c.newTargetHandler(ctxt, t)
 	if err != nil {
 		c.errf("could not create handler for %s: %v", t, err)
 		return
 	}
 
+	// listen for console messages before enabling the runtime and log
+	// domains, so that no messages are missed
+	if c.consolef != nil || len(c.console) != 0 {
+		h.Listen(c.consoleListener(ctxt, h), consoleEvents...)
+	}
+	if c.dialogf != nil {
+		h.SetDialogFunc(c.dialogf)
+	}
+	h.SetTimeouts(c.queryTimeout, c.waitTimeout)
+	h.SetQueryDiagnostics(c.queryDiagnostics)
+
 	// run
 	if err := h.Run(ctxt); err != nil {
+		h.stop()
 		c.errf("could not start handler for %s: %v", t, err)
 		return
 	}
@@ -153,6 +413,43 @@ func (c *CDP) AddTarget(ctxt context.Context, t client.Target) {
 	if c.cur == nil {
 		c.cur = h
 	}
+
+	c.notify()
+}
+
+// consoleListener returns a listener for h's events that passes the console
+// messages of h to consolef and the console message listeners.
+func (c *CDP) consoleListener(ctxt context.Context, h *TargetHandler) func(interface{}) {
+	consolef, console := c.consolef, c.console
+	return func(ev interface{}) {
+		m := newConsoleMessage(ctxt, h, ev)
+		if m == nil {
+			return
+		}
+		m.TargetID = h.Target().GetID()
+
+		if consolef != nil {
+			consolef("%s", m)
+		}
+		for _, f := range console {
+			f(m)
+		}
+	}
+}
+
+// newTargetHandler creates a handler for the target, attaching to the target
+// using a flattened session over the browser connection when enabled.
+func (c *CDP) newTargetHandler(ctxt context.Context, t client.Target) (*TargetHandler, error) {
+	if !c.flatten || c.b == nil {
+		return NewTargetHandler(t, c.logf, c.debugf, c.errf)
+	}
+
+	conn, err := c.b.Attach(ctxt, target.ID(t.GetID()))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTargetHandlerWithTransport(t, conn, c.logf, c.debugf, c.errf), nil
 }
 
 // Wait waits for the Chrome runner to terminate.
@@ -245,43 +542,99 @@ func (c *CDP) SetHandlerByID(id string) error {
 	return fmt.Errorf("no handler associated with target id %s", id)
 }
 
+// client returns a client for the managed browser using the supplied options.
+func (c *CDP) client(opts ...client.Option) (*client.Client, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	switch {
+	case c.r != nil:
+		return c.r.Client(opts...), nil
+
+	case c.cl != nil && len(opts) == 0:
+		return c.cl, nil
+	}
+
+	return nil, errors.New("no client available for target management")
+}
+
 // newTarget creates a new target using supplied context and options, returning
 // the id of the created target only after the target has been started for
 // monitoring.
 func (c *CDP) newTarget(ctxt context.Context, opts ...client.Option) (string, error) {
 	c.RLock()
-	cl := c.r.Client(opts...)
+	b := c.b
 	c.RUnlock()
 
-	// new page target
-	t, err := cl.NewPageTarget(ctxt)
-	if err != nil {
-		return "", err
+	var id string
+	if b != nil && len(opts) == 0 {
+		// new page target via the browser connection
+		targetID, err := target.CreateTarget("about:blank").Do(ctxt, b)
+		if err != nil {
+			return "", err
+		}
+		id = string(targetID)
+	} else {
+		cl, err := c.client(opts...)
+		if err != nil {
+			return "", err
+		}
+
+		// new page target
+		t, err := cl.NewPageTarget(ctxt)
+		if err != nil {
+			return "", err
+		}
+		id = t.GetID()
 	}
 
-	timeout := time.After(DefaultNewTargetTimeout)
+	return c.waitTarget(ctxt, id)
+}
 
-	for {
-		select {
-		default:
-			var ok bool
-			id := t.GetID()
-			c.RLock()
-			_, ok = c.handlerMap[id]
-			c.RUnlock()
-			if ok {
-				return id, nil
-			}
+// newIncognitoTarget creates a new target in a new browser context, returning
+// the id of the created target only after the target has been started for
+// monitoring.
+func (c *CDP) newIncognitoTarget(ctxt context.Context) (string, error) {
+	c.RLock()
+	b := c.b
+	c.RUnlock()
 
-			time.Sleep(DefaultCheckDuration)
+	if b == nil {
+		return "", ErrNoBrowser
+	}
 
-		case <-ctxt.Done():
-			return "", ctxt.Err()
+	contextID, err := target.CreateBrowserContext().Do(ctxt, b)
+	if err != nil {
+		return "", err
+	}
 
-		case <-timeout:
-			return "", errors.New("timeout waiting for new target to be available")
+	targetID, err := target.CreateTarget("about:blank").WithBrowserContextID(contextID).Do(ctxt, b)
+	if err != nil {
+		if e := target.DisposeBrowserContext(contextID).Do(ctxt, b); e != nil {
+			c.errf("could not dispose browser context %s: %v", contextID, e)
 		}
+		return "", err
+	}
+
+	c.Lock()
+	c.contexts[string(targetID)] = contextID
+	c.Unlock()
+
+	return c.waitTarget(ctxt, string(targetID))
+}
+
+// waitTarget waits until a handler for the target with the specified id has
+// been started, returning the id.
+func (c *CDP) waitTarget(ctxt context.Context, id string) (string, error) {
+	err := c.waitUntil(ctxt, DefaultNewTargetTimeout, "timeout waiting for new target to be available", func() bool {
+		_, ok := c.handlerMap[id]
+		return ok
+	})
+	if err != nil {
+		return "", err
 	}
+
+	return id, nil
 }
 
 // SetTarget is an action that sets the active Chrome handler to the specified
@@ -317,17 +670,197 @@ func (c *CDP) NewTarget(id *string, opts ...client.Option) Action {
 	})
 }
 
+// closeTarget closes the Chrome target for the handler with the specified
+// index, stopping the handler and removing it from the active handlers.
+func (c *CDP) closeTarget(ctxt context.Context, i int) error {
+	c.RLock()
+	if i < 0 || i >= len(c.handlers) {
+		c.RUnlock()
+		return fmt.Errorf("no handler associated with target index %d", i)
+	}
+	h, b := c.handlers[i], c.b
+	c.RUnlock()
+
+	if b != nil {
+		if _, err := target.CloseTarget(target.ID(h.Target().GetID())).Do(ctxt, b); err != nil {
+			return err
+		}
+	} else {
+		cl, err := c.client()
+		if err != nil {
+			return err
+		}
+
+		if err = cl.CloseTarget(ctxt, h.Target()); err != nil {
+			return err
+		}
+	}
+
+	c.removeHandler(h)
+
+	return nil
+}
+
+// removeHandler stops and removes h from the active handlers, selecting a new
+// current handler if h was the current handler.
+func (c *CDP) removeHandler(h *TargetHandler) {
+	h.stop()
+
+	c.Lock()
+	defer c.Unlock()
+
+	i := -1
+	for j, x := range c.handlers {
+		if x == h {
+			i = j
+			break
+		}
+	}
+	if i == -1 {
+		return
+	}
+
+	c.handlers = append(c.handlers[:i], c.handlers[i+1:]...)
+
+	// rebuild index map, as the indexes of later handlers have shifted
+	c.handlerMap = make(map[string]int, len(c.handlers))
+	for j, x := range c.handlers {
+		c.handlerMap[x.Target().GetID()] = j
+	}
+
+	defer c.notify()
+
+	if c.cur != h {
+		return
+	}
+
+	switch {
+	case len(c.handlers) == 0:
+		c.cur = nil
+
+	case i < len(c.handlers):
+		c.cur = c.handlers[i]
+
+	default:
+		c.cur = c.handlers[len(c.handlers)-1]
+	}
+}
+
+// NewIncognitoTarget is an action that creates a new Chrome target inside a
+// new browser context (similar to an incognito window), isolating the
+// target's cookies, storage, and cache from all other targets.
+//
+// The browser context is disposed when the target is closed.
+//
+// Note: requires the browser connection (ie, is not available when using
+// WithTargets).
+func (c *CDP) NewIncognitoTarget(id *string) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		n, err := c.newIncognitoTarget(ctxt)
+		if err != nil {
+			return err
+		}
+
+		if id != nil {
+			*id = n
+		}
+
+		return nil
+	})
+}
+
+// WaitNewTarget is an action that waits for a page target opened by the
+// current target (ie, via window.open or a link with target=_blank) to be
+// started, storing its id in id. When activate is true, the opened target is
+// set as the active target.
+//
+// Opened targets are returned in the order they were opened, and each opened
+// target is only returned once.
+//
+// Note: requires the browser connection (ie, is not available when using
+// WithTargets).
+func (c *CDP) WaitNewTarget(id *string, activate bool) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		c.RLock()
+		b := c.b
+		c.RUnlock()
+		if b == nil {
+			return ErrNoBrowser
+		}
+
+		opener := th.Target().GetID()
+
+		th.RLock()
+		timeout := th.waitTimeout
+		th.RUnlock()
+
+		var n string
+		err := c.waitUntil(ctxt, timeout, "timeout waiting for opened target", func() bool {
+			for _, x := range c.opened[opener] {
+				if _, ok := c.handlerMap[x]; ok {
+					n = x
+					return true
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return err
+		}
+
+		// claim the opened target
+		c.Lock()
+		ids := c.opened[opener]
+		for i, x := range ids {
+			if x == n {
+				c.opened[opener] = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		c.Unlock()
+
+		if id != nil {
+			*id = n
+		}
+
+		if activate {
+			return c.SetHandlerByID(n)
+		}
+
+		return nil
+	})
+}
+
 // CloseByIndex closes the Chrome target with specified index i.
+//
+// If the closed target was the active target, then the target that took its
+// index (or the last remaining target) becomes the active target.
 func (c *CDP) CloseByIndex(i int) Action {
 	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
-		return nil
+		return c.closeTarget(ctxt, i)
 	})
 }
 
 // CloseByID closes the Chrome target with the specified id.
+//
+// If the closed target was the active target, then the target that took its
+// index (or the last remaining target) becomes the active target.
 func (c *CDP) CloseByID(id string) Action {
 	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
-		return nil
+		c.RLock()
+		i, ok := c.handlerMap[id]
+		c.RUnlock()
+
+		if !ok {
+			return fmt.Errorf("no handler associated with target id %s", id)
+		}
+
+		return c.closeTarget(ctxt, i)
 	})
 }
 
@@ -341,6 +874,37 @@ func (c *CDP) Run(ctxt context.Context, a Action) error {
 	return a.Do(ctxt, cur)
 }
 
+// Intercept intercepts the requests of the current target matching any of the
+// patterns, returning a func that removes the interceptor. See
+// TargetHandler.Intercept.
+func (c *CDP) Intercept(ctxt context.Context, f InterceptFunc, patterns ...*fetch.RequestPattern) (func(context.Context) error, error) {
+	c.RLock()
+	cur := c.cur
+	c.RUnlock()
+
+	th, ok := cur.(*TargetHandler)
+	if !ok || th == nil {
+		return nil, ErrInvalidHandler
+	}
+
+	return th.Intercept(ctxt, f, patterns...)
+}
+
+// Bind exposes f to the current target's page javascript as the global func
+// name, returning a func that removes the binding. See TargetHandler.Bind.
+func (c *CDP) Bind(ctxt context.Context, name string, f BindFunc) (func(context.Context) error, error) {
+	c.RLock()
+	cur := c.cur
+	c.RUnlock()
+
+	th, ok := cur.(*TargetHandler)
+	if !ok || th == nil {
+		return nil, ErrInvalidHandler
+	}
+
+	return th.Bind(ctxt, name, f)
+}
+
 // Option is a Chrome DevTools Protocol option.
 type Option func(*CDP) error
 
@@ -354,7 +918,7 @@ func WithRunner(r *runner.Runner) Option {
 }
 
 // WithTargets is a CDP option to specify the incoming targets to monitor for
-// page handlers.
+// page handlers, instead of discovering targets over the browser connection.
 func WithTargets(watch <-chan client.Target) Option {
 	return func(c *CDP) error {
 		c.watch = watch
@@ -362,10 +926,16 @@ func WithTargets(watch <-chan client.Target) Option {
 	}
 }
 
-// WithClient is a CDP option to use the incoming targets from a client.
+// WithClient is a CDP option to use the targets of the browser the client is
+// connected to.
+//
+// Targets are discovered over the browser's websocket connection, falling
+// back to polling the client for page targets when the browser connection is
+// not available.
 func WithClient(ctxt context.Context, cl *client.Client) Option {
 	return func(c *CDP) error {
-		return WithTargets(cl.WatchPageTargets(ctxt))(c)
+		c.cl = cl
+		return nil
 	}
 }
 
@@ -376,6 +946,20 @@ func WithURL(ctxt context.Context, urlstr string) Option {
 	}
 }
 
+// WithFlattenedSessions is a CDP option to drive all targets over the single
+// browser connection, attaching to each target with a flattened session
+// (Target.attachToTarget with flatten set) instead of dialing the target's
+// own websocket URL.
+//
+// Note: has no effect when the browser connection is not available (ie, when
+// using WithTargets).
+func WithFlattenedSessions() Option {
+	return func(c *CDP) error {
+		c.flatten = true
+		return nil
+	}
+}
+
 // WithRunnerOptions is a CDP option to specify the options to pass to a newly
 // created Chrome process runner.
 func WithRunnerOptions(opts ...runner.CommandLineOption) Option {
@@ -419,11 +1003,84 @@ func WithLog(f func(string, ...interface{})) Option {
 	}
 }
 
-// WithConsolef is a CDP option to specify a func to receive chrome log events.
-//
-// Note: NOT YET IMPLEMENTED.
+// WithConsolef is a CDP option to specify a func to receive chrome log events
+// (ie, console API calls, uncaught exceptions, and log entries) of all targets,
+// formatted as text.
 func WithConsolef(f func(string, ...interface{})) Option {
 	return func(c *CDP) error {
+		c.consolef = f
+		return nil
+	}
+}
+
+// WithConsoleListener is a CDP option to specify a func to receive the
+// structured console messages (ie, console API calls, uncaught exceptions, and
+// log entries) of all targets.
+//
+// For example, to fail a test when a page throws an uncaught exception:
+//
+//	WithConsoleListener(func(m *ConsoleMessage) {
+//		if m.Exception != nil {
+//			t.Errorf("uncaught exception: %s", m)
+//		}
+//	})
+func WithConsoleListener(f func(*ConsoleMessage)) Option {
+	return func(c *CDP) error {
+		c.console = append(c.console, f)
+		return nil
+	}
+}
+
+// WithDialogFunc is a CDP option to specify a func to automatically handle the
+// javascript dialogs (alert, confirm, prompt, and beforeunload) opened by all
+// targets. The func is called for each dialog, and can use AcceptDialog,
+// DismissDialog, or AnswerPrompt as the default policy.
+//
+// For example, to accept all confirm dialogs, and dismiss all others:
+//
+//	WithDialogFunc(func(ev *page.EventJavascriptDialogOpening) (bool, string) {
+//		if ev.Type == page.DialogTypeConfirm {
+//			return AcceptDialog(ev)
+//		}
+//		return DismissDialog(ev)
+//	})
+func WithDialogFunc(f DialogFunc) Option {
+	return func(c *CDP) error {
+		c.dialogf = f
+		return nil
+	}
+}
+
+// WithQueryTimeout is a CDP option to specify the default time to wait for a
+// query's selector to match and for the query's conditions to be met (see
+// DefaultQueryTimeout). A timeout of 0 disables the default timeout.
+//
+// The timeout of an individual query can be specified with the WithTimeout
+// query option.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(c *CDP) error {
+		c.queryTimeout = d
+		return nil
+	}
+}
+
+// WithWaitTimeout is a CDP option to specify the default time to wait for a
+// frame or node to be loaded, for a javascript dialog to open, for a target
+// opened by the current target to be started, and for the network to be idle
+// (see DefaultWaitTimeout). A timeout of 0 disables the default timeout.
+func WithWaitTimeout(d time.Duration) Option {
+	return func(c *CDP) error {
+		c.waitTimeout = d
+		return nil
+	}
+}
+
+// WithQueryDiagnostics is a CDP option to capture a screenshot and the outer
+// HTML of the document when a query times out. The captured diagnostics are
+// available on the returned *QueryError.
+func WithQueryDiagnostics() Option {
+	return func(c *CDP) error {
+		c.queryDiagnostics = true
 		return nil
 	}
 }
@@ -5,9 +5,14 @@ import (
 	"log"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/target"
+
 	"github.com/chromedp/chromedp/runner"
 )
 
@@ -116,3 +121,128 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+func TestCloseByID(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	var id string
+	err := c.Run(defaultContext, c.CDP().NewTarget(&id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.CDP().ListTargets()); n != 2 {
+		t.Fatalf("expected 2 targets, got: %d", n)
+	}
+
+	err = c.Run(defaultContext, c.CDP().CloseByID(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.CDP().ListTargets()); n != 1 {
+		t.Errorf("expected 1 target, got: %d", n)
+	}
+	if h := c.CDP().GetHandlerByID(id); h != nil {
+		t.Errorf("expected handler for %s to be removed", id)
+	}
+}
+
+func TestNewIncognitoTarget(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	var id string
+	err := c.Run(defaultContext, c.CDP().NewIncognitoTarget(&id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.CDP().GetHandlerByID(id); h == nil {
+		t.Fatalf("expected handler for %s", id)
+	}
+
+	c.CDP().RLock()
+	contextID, ok := c.CDP().contexts[id]
+	b := c.CDP().b
+	c.CDP().RUnlock()
+	if !ok {
+		t.Fatalf("expected browser context for %s", id)
+	}
+
+	// cookies of the default browser context are not shared
+	const cookieURL = "http://chromedp.test/"
+	err = c.Run(defaultContext, ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		_, err := network.SetCookie("name", "value").WithURL(cookieURL).Do(ctxt, h)
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := network.GetCookies().WithUrls([]string{cookieURL}).Do(defaultContext, c.CDP().GetHandlerByID(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 0 {
+		t.Errorf("expected no cookies in the incognito target, got: %d", len(cookies))
+	}
+
+	err = c.Run(defaultContext, c.CDP().CloseByID(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the browser context is disposed with its target
+	ctxt, cancel := context.WithTimeout(defaultContext, 5*time.Second)
+	defer cancel()
+	for {
+		ids, err := target.GetBrowserContexts().Do(ctxt, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		disposed := true
+		for _, v := range ids {
+			if v == contextID {
+				disposed = false
+			}
+		}
+		if disposed {
+			break
+		}
+		select {
+		case <-time.After(DefaultCheckDuration):
+		case <-ctxt.Done():
+			t.Fatalf("expected browser context %s to be disposed", contextID)
+		}
+	}
+}
+
+func TestWaitNewTarget(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "popup.html")
+	defer c.Release()
+
+	var id string
+	err := c.Run(defaultContext, Tasks{
+		Click("#open-link", ByID),
+		c.CDP().WaitNewTarget(&id, true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" {
+		t.Fatal("expected opened target id")
+	}
+
+	var text string
+	err = c.Run(defaultContext, Text("#child1", &text, ByID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "child one"; !strings.Contains(text, exp) {
+		t.Errorf("expected text to contain %q, got: %q", exp, text)
+	}
+}
@@ -42,6 +42,10 @@ const (
 
 	// ErrUnsupportedProtocolVersion is the unsupported protocol version error.
 	ErrUnsupportedProtocolVersion Error = "unsupported protocol version"
+
+	// ErrMissingBrowserWebsocketURL is the missing browser websocket url
+	// error.
+	ErrMissingBrowserWebsocketURL Error = "missing browser websocket url"
 )
 
 // Target is the common interface for a Chrome DevTools Protocol target.
@@ -255,6 +259,22 @@ func (c *Client) VersionInfo(ctxt context.Context) (map[string]string, error) {
 	return v, nil
 }
 
+// BrowserWebsocketURL returns the websocket URL of the browser target, as
+// reported by the remote debugging protocol's version information.
+func (c *Client) BrowserWebsocketURL(ctxt context.Context) (string, error) {
+	v, err := c.VersionInfo(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	urlstr, ok := v["webSocketDebuggerUrl"]
+	if !ok || urlstr == "" {
+		return "", ErrMissingBrowserWebsocketURL
+	}
+
+	return urlstr, nil
+}
+
 // WatchPageTargets watches for new page targets.
 func (c *Client) WatchPageTargets(ctxt context.Context) <-chan Target {
 	ch := make(chan Target)
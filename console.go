@@ -0,0 +1,201 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// ConsoleMessage is a console API call, uncaught exception, or log entry
+// reported by a target.
+type ConsoleMessage struct {
+	// TargetID is the id of the target that reported the message.
+	TargetID string
+
+	// Source is the source of the message: "console" for console API calls,
+	// "exception" for uncaught exceptions, or the log entry source (ie,
+	// "network", "security", "violation") for log entries.
+	Source string
+
+	// Level is the message level (ie, "log", "info", "warning", "error").
+	Level string
+
+	// Text is the message text.
+	Text string
+
+	// Args are the JSON-encoded message arguments. Arguments that cannot be
+	// encoded as JSON are encoded as their string description.
+	Args []json.RawMessage
+
+	// StackTrace is the stack trace of the message, if available.
+	StackTrace *runtime.StackTrace
+
+	// URL, LineNumber, and ColumnNumber are the source location of the
+	// message, if available.
+	URL          string
+	LineNumber   int64
+	ColumnNumber int64
+
+	// Exception is the exception details, for uncaught exceptions.
+	Exception *runtime.ExceptionDetails
+}
+
+// consoleEvents are the events converted to console messages.
+var consoleEvents = []cdproto.MethodType{
+	cdproto.EventRuntimeConsoleAPICalled,
+	cdproto.EventRuntimeExceptionThrown,
+	cdproto.EventLogEntryAdded,
+}
+
+// newConsoleMessage converts a console API call, exception thrown, or log entry
+// added event to a console message, returning nil for any other event.
+func newConsoleMessage(ctxt context.Context, h cdp.Executor, ev interface{}) *ConsoleMessage {
+	switch e := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		m := &ConsoleMessage{
+			Source:     "console",
+			Level:      string(e.Type),
+			Args:       consoleArgs(ctxt, h, e.Args),
+			StackTrace: e.StackTrace,
+		}
+		m.Text = consoleText(e.Args)
+		m.setLocation(e.StackTrace)
+		return m
+
+	case *runtime.EventExceptionThrown:
+		d := e.ExceptionDetails
+		m := &ConsoleMessage{
+			Source:       "exception",
+			Level:        "error",
+			Text:         d.Text,
+			StackTrace:   d.StackTrace,
+			URL:          d.URL,
+			LineNumber:   d.LineNumber,
+			ColumnNumber: d.ColumnNumber,
+			Exception:    d,
+		}
+		if d.Exception != nil {
+			if d.Exception.Description != "" {
+				m.Text = d.Exception.Description
+			}
+			m.Args = consoleArgs(ctxt, h, []*runtime.RemoteObject{d.Exception})
+		}
+		if m.URL == "" {
+			m.setLocation(d.StackTrace)
+		}
+		return m
+
+	case *log.EventEntryAdded:
+		m := &ConsoleMessage{
+			Source:     string(e.Entry.Source),
+			Level:      string(e.Entry.Level),
+			Text:       e.Entry.Text,
+			Args:       consoleArgs(ctxt, h, e.Entry.Args),
+			StackTrace: e.Entry.StackTrace,
+			URL:        e.Entry.URL,
+			LineNumber: e.Entry.LineNumber,
+		}
+		if m.URL == "" {
+			m.setLocation(e.Entry.StackTrace)
+		}
+		return m
+	}
+
+	return nil
+}
+
+// setLocation sets the message's source location to the top call frame of the
+// stack trace.
+func (m *ConsoleMessage) setLocation(st *runtime.StackTrace) {
+	if st == nil || len(st.CallFrames) == 0 {
+		return
+	}
+
+	f := st.CallFrames[0]
+	m.URL, m.LineNumber, m.ColumnNumber = f.URL, f.LineNumber, f.ColumnNumber
+}
+
+// consoleText builds the text of a console API call from its arguments,
+// similar to how the DevTools console displays the call.
+func consoleText(args []*runtime.RemoteObject) string {
+	s := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case a.Type == runtime.TypeString:
+			var v string
+			if err := json.Unmarshal(a.Value, &v); err == nil {
+				s[i] = v
+				continue
+			}
+
+		case a.UnserializableValue != "":
+			s[i] = string(a.UnserializableValue)
+			continue
+
+		case a.Description != "":
+			s[i] = a.Description
+			continue
+		}
+
+		if len(a.Value) != 0 {
+			s[i] = string(a.Value)
+		} else {
+			s[i] = string(a.Type)
+		}
+	}
+
+	return strings.Join(s, " ")
+}
+
+// consoleArgs JSON-encodes the arguments, retrieving the values of
+// non-primitive arguments by value.
+func consoleArgs(ctxt context.Context, h cdp.Executor, args []*runtime.RemoteObject) []json.RawMessage {
+	if len(args) == 0 {
+		return nil
+	}
+
+	v := make([]json.RawMessage, len(args))
+	for i, a := range args {
+		switch {
+		case len(a.Value) != 0:
+			v[i] = json.RawMessage(a.Value)
+			continue
+
+		case a.Type == runtime.TypeUndefined:
+			v[i] = json.RawMessage(`null`)
+			continue
+
+		case a.ObjectID != "":
+			obj, exp, err := runtime.CallFunctionOn(`function() { return this; }`).
+				WithObjectID(a.ObjectID).
+				WithReturnByValue(true).
+				Do(ctxt, h)
+			if err == nil && exp == nil && len(obj.Value) != 0 {
+				v[i] = json.RawMessage(obj.Value)
+				continue
+			}
+		}
+
+		desc := a.Description
+		if desc == "" {
+			desc = string(a.UnserializableValue)
+		}
+		v[i], _ = json.Marshal(desc)
+	}
+
+	return v
+}
+
+// String satisfies the fmt.Stringer interface.
+func (m *ConsoleMessage) String() string {
+	if m.URL == "" {
+		return fmt.Sprintf("%s.%s: %s", m.Source, m.Level, m.Text)
+	}
+	return fmt.Sprintf("%s.%s: %s (%s:%d:%d)", m.Source, m.Level, m.Text, m.URL, m.LineNumber+1, m.ColumnNumber+1)
+}
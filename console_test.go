@@ -0,0 +1,99 @@
+package chromedp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestNewConsoleMessage(t *testing.T) {
+	t.Parallel()
+
+	stack := &runtime.StackTrace{
+		CallFrames: []*runtime.CallFrame{
+			{URL: "file:///js.html", LineNumber: 9, ColumnNumber: 4},
+		},
+	}
+
+	tests := []struct {
+		ev     interface{}
+		source string
+		level  string
+		text   string
+		args   []string
+		str    string
+	}{
+		{
+			&runtime.EventConsoleAPICalled{
+				Type: runtime.APITypeLog,
+				Args: []*runtime.RemoteObject{
+					{Type: runtime.TypeString, Value: []byte(`"count:"`)},
+					{Type: runtime.TypeNumber, Value: []byte(`5`), Description: "5"},
+					{Type: runtime.TypeUndefined},
+				},
+				StackTrace: stack,
+			},
+			"console", "log", "count: 5 undefined", []string{`"count:"`, `5`, `null`},
+			"console.log: count: 5 undefined (file:///js.html:10:5)",
+		},
+		{
+			&runtime.EventExceptionThrown{
+				ExceptionDetails: &runtime.ExceptionDetails{
+					Text: "Uncaught",
+					Exception: &runtime.RemoteObject{
+						Type:        runtime.TypeObject,
+						Subtype:     runtime.SubtypeError,
+						Description: "Error: boom",
+					},
+					StackTrace: stack,
+				},
+			},
+			"exception", "error", "Error: boom", []string{`"Error: boom"`},
+			"exception.error: Error: boom (file:///js.html:10:5)",
+		},
+		{
+			&log.EventEntryAdded{
+				Entry: &log.Entry{
+					Source: log.SourceNetwork,
+					Level:  log.LevelError,
+					Text:   "failed to load resource",
+				},
+			},
+			"network", "error", "failed to load resource", nil,
+			"network.error: failed to load resource",
+		},
+	}
+
+	for i, test := range tests {
+		m := newConsoleMessage(context.Background(), nil, test.ev)
+		if m == nil {
+			t.Fatalf("test %d expected message, got nil", i)
+		}
+		if m.Source != test.source {
+			t.Errorf("test %d expected source %q, got: %q", i, test.source, m.Source)
+		}
+		if m.Level != test.level {
+			t.Errorf("test %d expected level %q, got: %q", i, test.level, m.Level)
+		}
+		if m.Text != test.text {
+			t.Errorf("test %d expected text %q, got: %q", i, test.text, m.Text)
+		}
+		if len(m.Args) != len(test.args) {
+			t.Fatalf("test %d expected %d args, got: %d", i, len(test.args), len(m.Args))
+		}
+		for j, a := range test.args {
+			if string(m.Args[j]) != a {
+				t.Errorf("test %d expected arg %d to be %s, got: %s", i, j, a, string(m.Args[j]))
+			}
+		}
+		if s := m.String(); s != test.str {
+			t.Errorf("test %d expected string %q, got: %q", i, test.str, s)
+		}
+	}
+
+	if m := newConsoleMessage(context.Background(), nil, new(runtime.EventExecutionContextsCleared)); m != nil {
+		t.Errorf("expected nil message for non-console event, got: %v", m)
+	}
+}
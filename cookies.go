@@ -0,0 +1,253 @@
+package chromedp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// Cookies is an action that retrieves all of the browser's cookies.
+func Cookies(cookies *[]*network.Cookie) Action {
+	if cookies == nil {
+		panic("cookies cannot be nil")
+	}
+
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		var err error
+		*cookies, err = network.GetAllCookies().Do(ctxt, h)
+		return err
+	})
+}
+
+// SetCookies is an action that sets the browser's cookies.
+//
+// Cookies without a domain are set for the current page's URL, and cookies
+// without an expiration date are set as session cookies.
+func SetCookies(cookies ...*network.Cookie) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		if len(cookies) == 0 {
+			return nil
+		}
+
+		var urlstr string
+		params := make([]*network.CookieParam, len(cookies))
+		for i, c := range cookies {
+			params[i] = cookieParam(c)
+			if params[i].Domain != "" {
+				continue
+			}
+
+			// cookies without a domain must be associated with a URL
+			if urlstr == "" {
+				if err := Location(&urlstr).Do(ctxt, h); err != nil {
+					return err
+				}
+			}
+			params[i].URL = urlstr
+		}
+
+		return network.SetCookies(params).Do(ctxt, h)
+	})
+}
+
+// ClearCookies is an action that clears all of the browser's cookies.
+func ClearCookies() Action {
+	return network.ClearBrowserCookies()
+}
+
+// cookieParam converts a cookie to a cookie parameter.
+func cookieParam(c *network.Cookie) *network.CookieParam {
+	p := &network.CookieParam{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HTTPOnly: c.HTTPOnly,
+		SameSite: c.SameSite,
+	}
+
+	if !c.Session && c.Expires > 0 {
+		t := cdp.TimeSinceEpoch(cookieTime(c.Expires))
+		p.Expires = &t
+	}
+
+	return p
+}
+
+// cookieTime converts seconds since the UNIX epoch to a time.
+func cookieTime(expires float64) time.Time {
+	sec, frac := math.Modf(expires)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
+
+// HTTPCookies converts the browser's cookies to http cookies, suitable for use
+// with a http.CookieJar.
+func HTTPCookies(cookies []*network.Cookie) []*http.Cookie {
+	v := make([]*http.Cookie, len(cookies))
+	for i, c := range cookies {
+		hc := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+
+		if !c.Session && c.Expires > 0 {
+			hc.Expires = cookieTime(c.Expires).UTC()
+		}
+
+		switch c.SameSite {
+		case network.CookieSameSiteStrict:
+			hc.SameSite = http.SameSiteStrictMode
+		case network.CookieSameSiteLax:
+			hc.SameSite = http.SameSiteLaxMode
+		}
+
+		v[i] = hc
+	}
+	return v
+}
+
+// NetworkCookies converts http cookies to browser cookies, suitable for use
+// with SetCookies.
+func NetworkCookies(cookies []*http.Cookie) []*network.Cookie {
+	v := make([]*network.Cookie, len(cookies))
+	for i, hc := range cookies {
+		c := &network.Cookie{
+			Name:     hc.Name,
+			Value:    hc.Value,
+			Domain:   hc.Domain,
+			Path:     hc.Path,
+			Secure:   hc.Secure,
+			HTTPOnly: hc.HttpOnly,
+			Session:  hc.Expires.IsZero(),
+		}
+
+		if !c.Session {
+			c.Expires = float64(hc.Expires.UnixNano()) / float64(time.Second)
+		}
+
+		switch hc.SameSite {
+		case http.SameSiteStrictMode:
+			c.SameSite = network.CookieSameSiteStrict
+		case http.SameSiteLaxMode:
+			c.SameSite = network.CookieSameSiteLax
+		}
+
+		v[i] = c
+	}
+	return v
+}
+
+// WriteJSONCookies writes the cookies to w as a JSON array of http cookies
+// (ie, as encoded by json.Marshal for a []*http.Cookie).
+func WriteJSONCookies(w io.Writer, cookies []*network.Cookie) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(HTTPCookies(cookies))
+}
+
+// ReadJSONCookies reads cookies from a JSON array of http cookies (ie, as
+// written by WriteJSONCookies).
+func ReadJSONCookies(r io.Reader) ([]*network.Cookie, error) {
+	var cookies []*http.Cookie
+	if err := json.NewDecoder(r).Decode(&cookies); err != nil {
+		return nil, err
+	}
+	return NetworkCookies(cookies), nil
+}
+
+// netscapeHTTPOnlyPrefix is the domain prefix used for http-only cookies in
+// Netscape cookies.txt files.
+const netscapeHTTPOnlyPrefix = "#HttpOnly_"
+
+// WriteNetscapeCookies writes the cookies to w in the Netscape cookies.txt
+// format, as used by curl and wget.
+func WriteNetscapeCookies(w io.Writer, cookies []*network.Cookie) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+
+	for _, c := range cookies {
+		domain := c.Domain
+		if c.HTTPOnly {
+			domain = netscapeHTTPOnlyPrefix + domain
+		}
+
+		var expires int64
+		if !c.Session && c.Expires > 0 {
+			expires = int64(c.Expires)
+		}
+
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(strings.HasPrefix(c.Domain, ".")), c.Path,
+			netscapeBool(c.Secure), expires, c.Name, c.Value)
+	}
+
+	return bw.Flush()
+}
+
+// ReadNetscapeCookies reads cookies in the Netscape cookies.txt format.
+func ReadNetscapeCookies(r io.Reader) ([]*network.Cookie, error) {
+	var cookies []*network.Cookie
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		l := strings.TrimRight(s.Text(), "\r")
+
+		httpOnly := strings.HasPrefix(l, netscapeHTTPOnlyPrefix)
+		if httpOnly {
+			l = l[len(netscapeHTTPOnlyPrefix):]
+		}
+		if strings.TrimSpace(l) == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		f := strings.Split(l, "\t")
+		if len(f) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 fields, got: %d", line, len(f))
+		}
+
+		expires, err := strconv.ParseInt(f[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expires %q", line, f[4])
+		}
+
+		cookies = append(cookies, &network.Cookie{
+			Domain:   f[0],
+			Path:     f[2],
+			Secure:   strings.EqualFold(f[3], "TRUE"),
+			Expires:  float64(expires),
+			Session:  expires == 0,
+			Name:     f[5],
+			Value:    f[6],
+			HTTPOnly: httpOnly,
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return cookies, nil
+}
+
+// netscapeBool returns the Netscape cookies.txt representation of b.
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
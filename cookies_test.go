@@ -0,0 +1,113 @@
+package chromedp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+var testCookies = []*network.Cookie{
+	{Name: "session", Value: "abc", Domain: "localhost", Path: "/", HTTPOnly: true, Session: true},
+	{Name: "theme", Value: "dark", Domain: ".example.com", Path: "/app", Secure: true, Expires: 1893456000},
+}
+
+func TestNetscapeCookies(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := WriteNetscapeCookies(&buf, testCookies); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "# Netscape HTTP Cookie File\n" +
+		"#HttpOnly_localhost\tFALSE\t/\tFALSE\t0\tsession\tabc\n" +
+		".example.com\tTRUE\t/app\tTRUE\t1893456000\ttheme\tdark\n"
+	if buf.String() != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	cookies, err := ReadNetscapeCookies(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cookies, testCookies) {
+		t.Errorf("expected %v, got: %v", testCookies, cookies)
+	}
+
+	_, err = ReadNetscapeCookies(strings.NewReader("localhost\tFALSE\t/\n"))
+	if err == nil {
+		t.Error("expected error for invalid line")
+	}
+}
+
+func TestJSONCookies(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := WriteJSONCookies(&buf, testCookies); err != nil {
+		t.Fatal(err)
+	}
+
+	cookies, err := ReadJSONCookies(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cookies, testCookies) {
+		t.Errorf("expected %v, got: %v", testCookies, cookies)
+	}
+
+	hc := HTTPCookies(testCookies)
+	if !hc[0].Expires.IsZero() || !hc[0].HttpOnly {
+		t.Errorf("expected http-only session cookie, got: %v", hc[0])
+	}
+	if hc[1].Expires.Unix() != 1893456000 {
+		t.Errorf("expected expires 1893456000, got: %d", hc[1].Expires.Unix())
+	}
+}
+
+func TestCookies(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "server", Value: "1"})
+	}))
+	defer s.Close()
+
+	var cookies []*network.Cookie
+	err := c.Run(defaultContext, Tasks{
+		ClearCookies(),
+		Navigate(s.URL),
+		SetCookies(&network.Cookie{Name: "client", Value: "2", Session: true}),
+		Cookies(&cookies),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]string)
+	for _, c := range cookies {
+		values[c.Name] = c.Value
+	}
+	if values["server"] != "1" || values["client"] != "2" {
+		t.Errorf("expected server=1 and client=2 cookies, got: %v", values)
+	}
+
+	err = c.Run(defaultContext, Tasks{
+		ClearCookies(),
+		Cookies(&cookies),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 0 {
+		t.Errorf("expected no cookies, got: %v", cookies)
+	}
+}
@@ -0,0 +1,159 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+)
+
+// DialogFunc is a func that handles a javascript dialog (alert, confirm,
+// prompt, or beforeunload), returning whether to accept the dialog, and the
+// text to enter for prompt dialogs.
+type DialogFunc func(*page.EventJavascriptDialogOpening) (accept bool, promptText string)
+
+// AcceptDialog is a dialog func that accepts all dialogs, entering the default
+// prompt text for prompt dialogs.
+func AcceptDialog(ev *page.EventJavascriptDialogOpening) (bool, string) {
+	return true, ev.DefaultPrompt
+}
+
+// DismissDialog is a dialog func that dismisses all dialogs.
+func DismissDialog(ev *page.EventJavascriptDialogOpening) (bool, string) {
+	return false, ""
+}
+
+// AnswerPrompt returns a dialog func that accepts all dialogs, entering text
+// for prompt dialogs.
+func AnswerPrompt(text string) DialogFunc {
+	return func(ev *page.EventJavascriptDialogOpening) (bool, string) {
+		return true, text
+	}
+}
+
+// SetDialogFunc sets the func used to automatically handle the javascript
+// dialogs opened by the target. When f is nil, dialogs are left open until
+// handled with HandleDialog.
+//
+// Note: while a dialog is open, the page's javascript execution is paused, and
+// any action waiting on the page will not complete.
+func (h *TargetHandler) SetDialogFunc(f DialogFunc) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.dialogf = f
+}
+
+// dialogOpening handles a javascript dialog opening, automatically handling
+// the dialog when a dialog func is set.
+func (h *TargetHandler) dialogOpening(ctxt context.Context, ev *page.EventJavascriptDialogOpening) {
+	h.Lock()
+	f := h.dialogf
+	if f == nil {
+		h.dialog = ev
+		close(h.dialogOpened)
+		h.dialogOpened = make(chan struct{})
+	}
+	h.Unlock()
+
+	if f == nil {
+		return
+	}
+
+	go func() {
+		accept, text := f(ev)
+		err := page.HandleJavaScriptDialog(accept).WithPromptText(text).Do(ctxt, h)
+		if err != nil {
+			h.errf("could not handle %s dialog: %v", ev.Type, err)
+		}
+	}()
+}
+
+// dialogClosed clears the open javascript dialog.
+func (h *TargetHandler) dialogClosed() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.dialog = nil
+}
+
+// dialogHandled clears the open javascript dialog when it is still the
+// handled dialog ev, and not a dialog opened since.
+func (h *TargetHandler) dialogHandled(ev *page.EventJavascriptDialogOpening) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.dialog == ev {
+		h.dialog = nil
+	}
+}
+
+// WaitDialog is an action that waits until a javascript dialog (alert,
+// confirm, prompt, or beforeunload) is open on the page, storing the dialog's
+// message in msg. An error is returned when no dialog opens before the
+// handler's wait timeout expires.
+//
+// Note: dialogs automatically handled by the handler's dialog func are not
+// returned.
+func WaitDialog(msg *string) Action {
+	if msg == nil {
+		panic("msg cannot be nil")
+	}
+
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		timeout := th.waitTimeoutChan()
+
+		for {
+			th.RLock()
+			ev, opened := th.dialog, th.dialogOpened
+			th.RUnlock()
+
+			if ev != nil {
+				*msg = ev.Message
+				return nil
+			}
+
+			select {
+			case <-opened:
+
+			case <-ctxt.Done():
+				return ctxt.Err()
+
+			case <-timeout:
+				return errors.New("timeout waiting for dialog")
+			}
+		}
+	})
+}
+
+// HandleDialog is an action that accepts or dismisses the open javascript
+// dialog, entering promptText for prompt dialogs.
+func HandleDialog(accept bool, promptText string) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		th.RLock()
+		ev := th.dialog
+		th.RUnlock()
+
+		err := page.HandleJavaScriptDialog(accept).WithPromptText(promptText).Do(ctxt, th)
+		if err != nil {
+			return err
+		}
+
+		// the dialog is also cleared by the dialog closed event, but a
+		// following WaitDialog must not see the handled dialog
+		th.dialogHandled(ev)
+
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package chromedp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestWaitDialogOpened(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{dialogOpened: make(chan struct{})}
+
+	// the waiting action is signalled when the dialog opens
+	errc := make(chan error, 1)
+	var msg string
+	go func() {
+		errc <- WaitDialog(&msg).Do(defaultContext, h)
+	}()
+
+	alert := &page.EventJavascriptDialogOpening{
+		Type:    page.DialogTypeAlert,
+		Message: "hello",
+	}
+	h.dialogOpening(context.Background(), alert)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if exp := "hello"; msg != exp {
+		t.Errorf("expected %q, got: %q", exp, msg)
+	}
+
+	// a dialog opened before the handled dialog is cleared is kept
+	h.dialogClosed()
+	confirm := &page.EventJavascriptDialogOpening{
+		Type:    page.DialogTypeConfirm,
+		Message: "are you sure?",
+	}
+	h.dialogOpening(context.Background(), confirm)
+	h.dialogHandled(alert)
+	if err := WaitDialog(&msg).Do(defaultContext, h); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "are you sure?"; msg != exp {
+		t.Errorf("expected %q, got: %q", exp, msg)
+	}
+
+	h.dialogHandled(confirm)
+	if h.dialog != nil {
+		t.Errorf("expected no open dialog, got: %v", h.dialog)
+	}
+}
+
+func TestWaitDialogTimeout(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{dialogOpened: make(chan struct{})}
+	h.SetTimeouts(0, 50*time.Millisecond)
+
+	var msg string
+	err := WaitDialog(&msg).Do(defaultContext, h)
+	if err == nil || err.Error() != "timeout waiting for dialog" {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+}
+
+func TestHandleDialog(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "alert.html")
+	defer c.Release()
+
+	var alert, confirm string
+	err := c.Run(defaultContext, Tasks{
+		WaitDialog(&alert),
+		HandleDialog(true, ""),
+		WaitDialog(&confirm),
+		HandleDialog(false, ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alert != "alert1" {
+		t.Errorf("expected alert1, got: %q", alert)
+	}
+	if confirm != "confirm1" {
+		t.Errorf("expected confirm1, got: %q", confirm)
+	}
+
+	var value string
+	err = c.Run(defaultContext, Value("#input1", &value, ByID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "input value1"; value != exp {
+		t.Errorf("expected %q, got: %q", exp, value)
+	}
+}
@@ -39,4 +39,16 @@ const (
 
 	// ErrInvalidHandler is the invalid handler error.
 	ErrInvalidHandler Error = "invalid handler"
+
+	// ErrRequestResolved is the request already resolved error.
+	ErrRequestResolved Error = "request already resolved"
+
+	// ErrNoBrowser is the no browser connection error.
+	ErrNoBrowser Error = "no browser connection"
+
+	// ErrInvalidMessage is the invalid message error.
+	ErrInvalidMessage Error = "invalid message"
+
+	// ErrHARNotStarted is the HAR recording not started error.
+	ErrHARNotStarted Error = "HAR recording not started"
 )
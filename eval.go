@@ -3,8 +3,10 @@ package chromedp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/runtime"
 )
 
@@ -50,18 +52,70 @@ func Evaluate(expression string, res interface{}, opts ...EvaluateOption) Action
 			return exp
 		}
 
-		switch x := res.(type) {
-		case **runtime.RemoteObject:
-			*x = v
-			return nil
+		return decodeResult(v, res)
+	})
+}
+
+// decodeResult decodes the remote object v to res, as described by Evaluate.
+func decodeResult(v *runtime.RemoteObject, res interface{}) error {
+	switch x := res.(type) {
+	case **runtime.RemoteObject:
+		*x = v
+		return nil
+
+	case *[]byte:
+		*x = []byte(v.Value)
+		return nil
+	}
+
+	// unmarshal
+	return json.Unmarshal(v.Value, res)
+}
+
+// CallFunction is an action to call the Javascript function fn, with the
+// global object (ie, window) as this, and with args JSON-encoded as the
+// function's arguments. When fn returns a promise, the promise is awaited.
+//
+// The result of the call is unmarshaled to res as described by Evaluate.
+//
+// Note: any exception encountered will be returned as an error.
+func CallFunction(fn string, res interface{}, args ...interface{}) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		callArgs, err := callArguments(args...)
+		if err != nil {
+			return err
+		}
 
-		case *[]byte:
-			*x = []byte(v.Value)
-			return nil
+		global, exp, err := runtime.Evaluate("window").Do(ctxt, h)
+		if err != nil {
+			return err
+		}
+		if exp != nil {
+			return exp
+		}
+		defer runtime.ReleaseObject(global.ObjectID).Do(ctxt, h)
+
+		p := runtime.CallFunctionOn(fn).
+			WithObjectID(global.ObjectID).
+			WithArguments(callArgs).
+			WithAwaitPromise(true)
+		if _, ok := res.(**runtime.RemoteObject); !ok {
+			p = p.WithReturnByValue(true)
+		}
+
+		v, exp, err := p.Do(ctxt, h)
+		if err != nil {
+			return err
+		}
+		if exp != nil {
+			return exp
 		}
 
-		// unmarshal
-		return json.Unmarshal(v.Value, res)
+		return decodeResult(v, res)
 	})
 }
 
@@ -98,8 +152,103 @@ func EvalIgnoreExceptions(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithSilent(true)
 }
 
+// EvalAwaitPromise is an evaluate option that will cause script evaluation to
+// wait for the promise returned by the evaluated script to be resolved,
+// returning the promise's result (or its rejection as an error).
+func EvalAwaitPromise(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithAwaitPromise(true)
+}
+
 // EvalAsValue is a evaluate option that will cause the evaluated script to
 // encode the result of the expression as a JSON-encoded value.
 func EvalAsValue(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithReturnByValue(true)
 }
+
+// remoteObjectValue returns the JSON-encoded value of the remote object,
+// retrieving the value of non-primitive objects by value.
+func remoteObjectValue(ctxt context.Context, h cdp.Executor, o *runtime.RemoteObject) (json.RawMessage, error) {
+	switch {
+	case len(o.Value) != 0:
+		return json.RawMessage(o.Value), nil
+
+	case o.Type == runtime.TypeUndefined:
+		return json.RawMessage(`null`), nil
+
+	case o.ObjectID == "":
+		return nil, fmt.Errorf("could not encode %s value as JSON", o.Type)
+	}
+
+	v, exp, err := runtime.CallFunctionOn(`function() { return this; }`).
+		WithObjectID(o.ObjectID).
+		WithReturnByValue(true).
+		Do(ctxt, h)
+	switch {
+	case err != nil:
+		return nil, err
+	case exp != nil:
+		return nil, exp
+	case len(v.Value) == 0:
+		return nil, fmt.Errorf("could not encode %s value as JSON", o.Type)
+	}
+
+	return json.RawMessage(v.Value), nil
+}
+
+// callNodeFunc calls the javascript function fn with the node n as this, and
+// the JSON-encoded args as arguments, unmarshaling the function's result to
+// res (when res is not nil). As with Evaluate, res can be a *[]byte or a
+// **runtime.RemoteObject.
+//
+// Unlike scripts locating nodes by XPath, fn works on nodes inside shadow
+// roots and iframes.
+func callNodeFunc(ctxt context.Context, h cdp.Executor, n *cdp.Node, fn string, res interface{}, args ...interface{}) error {
+	obj, err := dom.ResolveNode().WithNodeID(n.NodeID).Do(ctxt, h)
+	if err != nil {
+		return err
+	}
+	defer runtime.ReleaseObject(obj.ObjectID).Do(ctxt, h)
+
+	callArgs, err := callArguments(args...)
+	if err != nil {
+		return err
+	}
+
+	p := runtime.CallFunctionOn(fn).
+		WithObjectID(obj.ObjectID).
+		WithArguments(callArgs)
+	if _, ok := res.(**runtime.RemoteObject); !ok {
+		p = p.WithReturnByValue(true)
+	}
+
+	v, exp, err := p.Do(ctxt, h)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+
+	if res == nil {
+		return nil
+	}
+	if _, ok := res.(**runtime.RemoteObject); !ok && len(v.Value) == 0 {
+		return nil
+	}
+
+	return decodeResult(v, res)
+}
+
+// callArguments returns the JSON-encoded args as function call arguments.
+func callArguments(args ...interface{}) ([]*runtime.CallArgument, error) {
+	callArgs := make([]*runtime.CallArgument, len(args))
+	for i, arg := range args {
+		buf, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		callArgs[i] = &runtime.CallArgument{Value: buf}
+	}
+
+	return callArgs, nil
+}
@@ -0,0 +1,66 @@
+package chromedp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestEvalAwaitPromise(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	var res int
+	err := c.Run(defaultContext, Evaluate(`new Promise(function(resolve) {
+	setTimeout(function() { resolve(42); }, 50);
+})`, &res, EvalAwaitPromise))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if res != 42 {
+		t.Errorf("expected 42, got: %d", res)
+	}
+
+	err = c.Run(defaultContext, Evaluate(`Promise.reject(new Error('rejected'))`, &res, EvalAwaitPromise))
+	if exp, ok := err.(*runtime.ExceptionDetails); !ok || exp.Exception == nil || !strings.Contains(exp.Exception.Description, "rejected") {
+		t.Errorf("expected rejected exception, got: %v", err)
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	var res struct {
+		Sum  int    `json:"sum"`
+		Text string `json:"text"`
+	}
+	err := c.Run(defaultContext, CallFunction(`function(a, b, s) {
+	return Promise.resolve({sum: a.x + b, text: s + '!'});
+}`, &res, map[string]int{"x": 1}, 2, `it's "quoted"`))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if res.Sum != 3 || res.Text != `it's "quoted"!` {
+		t.Errorf("expected 3 and quoted text, got: %+v", res)
+	}
+
+	var obj *runtime.RemoteObject
+	if err = c.Run(defaultContext, CallFunction(`function() { return this; }`, &obj)); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if obj == nil || obj.ClassName != "Window" {
+		t.Errorf("expected Window object, got: %v", obj)
+	}
+
+	var raw []byte
+	err = c.Run(defaultContext, CallFunction(`function() { throw new Error('thrown'); }`, &raw))
+	if exp, ok := err.(*runtime.ExceptionDetails); !ok || exp.Exception == nil || !strings.Contains(exp.Exception.Description, "thrown") {
+		t.Errorf("expected thrown exception, got: %v", err)
+	}
+}
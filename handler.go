@@ -16,10 +16,12 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/css"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/inspector"
 	"github.com/chromedp/cdproto/log"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
 
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp/client"
@@ -29,6 +31,28 @@ import (
 type TargetHandler struct {
 	conn client.Transport
 
+	// target is the client target the handler was created for.
+	target client.Target
+
+	// cancel stops the run loop started by Run.
+	cancel func()
+
+	// sessionID is the flattened session id of conn, if any.
+	sessionID target.SessionID
+
+	// mux is the multiplexer for the flattened sessions of child targets.
+	mux *sessionMux
+
+	// wm serializes writes to conn.
+	wm sync.Mutex
+
+	// parent is the handler of the parent target, when the handler is for
+	// an out-of-process iframe.
+	parent *TargetHandler
+
+	// children is the map of out-of-process iframe IDs to their handlers.
+	children map[cdp.FrameID]*TargetHandler
+
 	// frames is the set of encountered frames.
 	frames map[cdp.FrameID]*cdp.Frame
 
@@ -57,6 +81,59 @@ type TargetHandler struct {
 	res   map[int64]chan *cdproto.Message
 	resrw sync.RWMutex
 
+	// dialog is the open javascript dialog, if any.
+	dialog *page.EventJavascriptDialogOpening
+
+	// dialogOpened is closed and replaced when a javascript dialog opens.
+	dialogOpened chan struct{}
+
+	// dialogf is the func used to automatically handle javascript dialogs.
+	dialogf DialogFunc
+
+	// lifecycle is the map of frame ids to the lifecycle events fired for
+	// the frame's current loader.
+	lifecycle map[cdp.FrameID]*frameLifecycle
+
+	// responses is the map of frame ids to the frame's last document
+	// response.
+	responses map[cdp.FrameID]*network.EventResponseReceived
+
+	// failures is the map of loader ids to the error text of the loader's
+	// failed document request.
+	failures map[cdp.LoaderID]string
+
+	// navUpdate is closed and replaced when a lifecycle event, a document
+	// response, or a document request failure is received.
+	navUpdate chan struct{}
+
+	// inflight is the map of in-flight request ids to their URLs.
+	inflight map[network.RequestID]string
+
+	// queryTimeout and waitTimeout are the default query and frame/node
+	// wait timeouts.
+	queryTimeout, waitTimeout time.Duration
+
+	// queryDiagnostics toggles capturing a screenshot and the document's
+	// outer HTML when a query times out.
+	queryDiagnostics bool
+
+	// har is the HAR recorder, when recording network activity.
+	har *harRecorder
+
+	// interceptors are the registered request interceptors.
+	interceptors []*interceptor
+	irw          sync.Mutex
+
+	// bindings are the Go funcs exposed to page javascript. bmu serializes
+	// adding and removing bindings, which brw is not held across.
+	bindings map[string]*binding
+	brw      sync.RWMutex
+	bmu      sync.Mutex
+
+	// listeners are the registered event listeners.
+	listeners []*listener
+	lrw       sync.RWMutex
+
 	// logging funcs
 	logf, debugf, errf func(string, ...interface{})
 
@@ -70,12 +147,31 @@ func NewTargetHandler(t client.Target, logf, debugf, errf func(string, ...interf
 		return nil, err
 	}
 
-	return &TargetHandler{
-		conn:   conn,
-		logf:   logf,
-		debugf: debugf,
-		errf:   errf,
-	}, nil
+	return NewTargetHandlerWithTransport(t, conn, logf, debugf, errf), nil
+}
+
+// NewTargetHandlerWithTransport creates a new handler for the specified client
+// target, using conn to send and receive messages (ie, a flattened session
+// returned by Browser.Attach).
+func NewTargetHandlerWithTransport(t client.Target, conn client.Transport, logf, debugf, errf func(string, ...interface{})) *TargetHandler {
+	h := &TargetHandler{
+		conn:         conn,
+		target:       t,
+		queryTimeout: DefaultQueryTimeout,
+		waitTimeout:  DefaultWaitTimeout,
+		logf:         logf,
+		debugf:       debugf,
+		errf:         errf,
+	}
+
+	// child sessions of a flattened session share its connection
+	if s, ok := conn.(*session); ok {
+		h.sessionID, h.mux = s.id, s.m
+	} else {
+		h.mux = newSessionMux(h.write, nil)
+	}
+
+	return h
 }
 
 // Run starts the processing of commands and events of the client target
@@ -86,6 +182,14 @@ func (h *TargetHandler) Run(ctxt context.Context) error {
 	// reset
 	h.Lock()
 	h.frames = make(map[cdp.FrameID]*cdp.Frame)
+	h.children = make(map[cdp.FrameID]*TargetHandler)
+	h.lifecycle = make(map[cdp.FrameID]*frameLifecycle)
+	h.responses = make(map[cdp.FrameID]*network.EventResponseReceived)
+	h.failures = make(map[cdp.LoaderID]string)
+	h.navUpdate = make(chan struct{})
+	h.inflight = make(map[network.RequestID]string)
+	h.bindings = make(map[string]*binding)
+	h.dialogOpened = make(chan struct{})
 	h.qcmd = make(chan *cdproto.Message)
 	h.qres = make(chan *cdproto.Message)
 	h.qevents = make(chan *cdproto.Message)
@@ -93,6 +197,7 @@ func (h *TargetHandler) Run(ctxt context.Context) error {
 	h.detached = make(chan *inspector.EventDetached, 1)
 	h.pageWaitGroup = new(sync.WaitGroup)
 	h.domWaitGroup = new(sync.WaitGroup)
+	ctxt, h.cancel = context.WithCancel(ctxt)
 	h.Unlock()
 
 	// run
@@ -105,6 +210,7 @@ func (h *TargetHandler) Run(ctxt context.Context) error {
 		network.Enable(),
 		inspector.Enable(),
 		page.Enable(),
+		page.SetLifecycleEventsEnabled(true),
 		dom.Enable(),
 		css.Enable(),
 	} {
@@ -113,6 +219,12 @@ func (h *TargetHandler) Run(ctxt context.Context) error {
 		}
 	}
 
+	// auto attach to out-of-process iframes
+	err := target.SetAutoAttach(true, false).WithFlatten(true).Do(ctxt, h)
+	if err != nil {
+		h.errf("could not auto attach to out-of-process iframes: %v", err)
+	}
+
 	h.Lock()
 
 	// get page resources
@@ -135,9 +247,113 @@ func (h *TargetHandler) Run(ctxt context.Context) error {
 	return nil
 }
 
+// Target returns the client target the handler was created for.
+func (h *TargetHandler) Target() client.Target {
+	return h.target
+}
+
+// stop stops the run loop started by Run, closing the client connection.
+func (h *TargetHandler) stop() {
+	h.RLock()
+	cancel := h.cancel
+	h.RUnlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// SetTimeouts sets the default time to wait for a query's selector to match
+// and for the query's conditions to be met, and the default time to wait for
+// a frame or node to be loaded, for a javascript dialog to open, for a target
+// opened by the target to be started, and for the network to be idle. A
+// timeout of 0 disables the default timeout.
+func (h *TargetHandler) SetTimeouts(query, wait time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.queryTimeout, h.waitTimeout = query, wait
+}
+
+// SetQueryDiagnostics toggles capturing a screenshot and the document's outer
+// HTML when a query times out.
+func (h *TargetHandler) SetQueryDiagnostics(enabled bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.queryDiagnostics = enabled
+}
+
+// FrameHandler returns the handler of the out-of-process iframe with the
+// specified id, or nil if the frame is not an out-of-process iframe.
+func (h *TargetHandler) FrameHandler(id cdp.FrameID) *TargetHandler {
+	h.RLock()
+	defer h.RUnlock()
+
+	return h.children[id]
+}
+
+// attachChild starts a handler for an auto attached out-of-process iframe
+// target, linking it to the iframe's frame.
+func (h *TargetHandler) attachChild(ctxt context.Context, ev *target.EventAttachedToTarget) {
+	if ev.TargetInfo.Type != client.Iframe.String() {
+		return
+	}
+
+	t := &client.Chrome{
+		ID:   string(ev.TargetInfo.TargetID),
+		Type: client.Iframe,
+		URL:  ev.TargetInfo.URL,
+	}
+
+	child := NewTargetHandlerWithTransport(t, h.mux.addSession(ev.SessionID), h.logf, h.debugf, h.errf)
+	child.parent = h
+
+	h.RLock()
+	child.queryTimeout, child.waitTimeout = h.queryTimeout, h.waitTimeout
+	child.queryDiagnostics = h.queryDiagnostics
+	h.RUnlock()
+
+	if err := child.Run(ctxt); err != nil {
+		child.stop()
+		h.errf("could not start handler for %s: %v", t, err)
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	// the frame id of an out-of-process iframe is its target id
+	h.children[cdp.FrameID(t.ID)] = child
+}
+
+// detachChild stops the handler for the out-of-process iframe target with the
+// specified session id.
+func (h *TargetHandler) detachChild(id target.SessionID) {
+	h.Lock()
+	var child *TargetHandler
+	for frameID, c := range h.children {
+		if c.sessionID == id {
+			child = c
+			delete(h.children, frameID)
+			break
+		}
+	}
+	h.Unlock()
+
+	if child != nil {
+		child.stop()
+	}
+	h.mux.removeSession(id)
+}
+
 // run handles the actual message processing to / from the web socket connection.
 func (h *TargetHandler) run(ctxt context.Context) {
 	defer h.conn.Close()
+	defer h.closeListeners()
+	if h.sessionID == "" {
+		defer h.mux.closeSessions()
+	}
 
 	// add cancel to context
 	ctxt, cancel := context.WithCancel(ctxt)
@@ -202,24 +418,40 @@ func (h *TargetHandler) run(ctxt context.Context) {
 	}
 }
 
-// read reads a message from the client connection.
+// read reads a message from the client connection, routing messages for child
+// sessions to their transports.
 func (h *TargetHandler) read() (*cdproto.Message, error) {
-	// read
-	buf, err := h.conn.Read()
-	if err != nil {
-		return nil, err
-	}
+	for {
+		// read
+		buf, err := h.conn.Read()
+		if err != nil {
+			return nil, err
+		}
 
-	h.debugf("-> %s", string(buf))
+		h.debugf("-> %s", string(buf))
 
-	// unmarshal
-	msg := new(cdproto.Message)
-	err = json.Unmarshal(buf, msg)
-	if err != nil {
-		return nil, err
+		// unmarshal
+		msg := new(browserMessage)
+		err = json.Unmarshal(buf, msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg.SessionID != "" && msg.SessionID != h.sessionID {
+			h.mux.route(msg.SessionID, buf)
+			continue
+		}
+
+		return msg.message(), nil
 	}
+}
 
-	return msg, nil
+// write writes a message to the client connection.
+func (h *TargetHandler) write(buf []byte) error {
+	h.wm.Lock()
+	defer h.wm.Unlock()
+
+	return h.conn.Write(buf)
 }
 
 // processEvent processes an incoming event.
@@ -234,6 +466,8 @@ func (h *TargetHandler) processEvent(ctxt context.Context, msg *cdproto.Message)
 		return err
 	}
 
+	h.dispatch(msg.Method, ev)
+
 	switch e := ev.(type) {
 	case *inspector.EventDetached:
 		h.Lock()
@@ -245,6 +479,47 @@ func (h *TargetHandler) processEvent(ctxt context.Context, msg *cdproto.Message)
 		h.domWaitGroup.Wait()
 		go h.documentUpdated(ctxt)
 		return nil
+
+	case *page.EventLifecycleEvent:
+		h.lifecycleEvent(e)
+		return nil
+
+	case *page.EventJavascriptDialogOpening:
+		h.dialogOpening(ctxt, e)
+		return nil
+
+	case *page.EventJavascriptDialogClosed:
+		h.dialogClosed()
+		return nil
+
+	case *network.EventRequestWillBeSent, *network.EventResponseReceived,
+		*network.EventDataReceived, *network.EventLoadingFinished,
+		*network.EventLoadingFailed:
+		h.trackRequest(ev)
+
+		h.RLock()
+		r := h.har
+		h.RUnlock()
+		if r != nil {
+			r.event(ctxt, h, ev)
+		}
+		return nil
+
+	case *fetch.EventRequestPaused:
+		go h.requestPaused(ctxt, e)
+		return nil
+
+	case *runtime.EventBindingCalled:
+		go h.bindingCalled(ctxt, e)
+		return nil
+
+	case *target.EventAttachedToTarget:
+		go h.attachChild(ctxt, e)
+		return nil
+
+	case *target.EventDetachedFromTarget:
+		go h.detachChild(e.SessionID)
+		return nil
 	}
 
 	d := msg.Method.Domain()
@@ -265,6 +540,109 @@ func (h *TargetHandler) processEvent(ctxt context.Context, msg *cdproto.Message)
 	return nil
 }
 
+// listener is a target event listener.
+type listener struct {
+	f       func(interface{})
+	methods map[cdproto.MethodType]bool
+	queue   chan interface{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// run delivers queued events to the listener's func.
+func (l *listener) run() {
+	for {
+		select {
+		case ev := <-l.queue:
+			l.f(ev)
+
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// close stops the listener.
+func (l *listener) close() {
+	l.once.Do(func() {
+		close(l.done)
+	})
+}
+
+// Listen registers f to be called with every decoded event (ie,
+// *network.EventRequestWillBeSent, *runtime.EventConsoleAPICalled) received
+// by the handler, returning a func that unregisters the listener. When methods
+// are provided, only events with those methods (ie,
+// cdproto.EventNetworkRequestWillBeSent) are delivered.
+//
+// Events are delivered to f in order, on a goroutine per listener, from a
+// queue of DefaultListenerQueueSize events. The handler never blocks on a
+// listener: when a listener's queue is full, further events for that listener
+// are dropped until f catches up.
+func (h *TargetHandler) Listen(f func(ev interface{}), methods ...cdproto.MethodType) func() {
+	l := &listener{
+		f:     f,
+		queue: make(chan interface{}, DefaultListenerQueueSize),
+		done:  make(chan struct{}),
+	}
+
+	if len(methods) != 0 {
+		l.methods = make(map[cdproto.MethodType]bool, len(methods))
+		for _, m := range methods {
+			l.methods[m] = true
+		}
+	}
+
+	h.lrw.Lock()
+	h.listeners = append(h.listeners, l)
+	h.lrw.Unlock()
+
+	go l.run()
+
+	return func() {
+		l.close()
+
+		h.lrw.Lock()
+		defer h.lrw.Unlock()
+
+		for i, x := range h.listeners {
+			if x == l {
+				h.listeners = append(h.listeners[:i:i], h.listeners[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// dispatch queues the event for the listeners registered for method.
+func (h *TargetHandler) dispatch(method cdproto.MethodType, ev interface{}) {
+	h.lrw.RLock()
+	defer h.lrw.RUnlock()
+
+	for _, l := range h.listeners {
+		if l.methods != nil && !l.methods[method] {
+			continue
+		}
+
+		select {
+		case l.queue <- ev:
+		default:
+			h.debugf("dropped event %s for slow listener", method)
+		}
+	}
+}
+
+// closeListeners stops all registered listeners.
+func (h *TargetHandler) closeListeners() {
+	h.lrw.Lock()
+	defer h.lrw.Unlock()
+
+	for _, l := range h.listeners {
+		l.close()
+	}
+	h.listeners = nil
+}
+
 // documentUpdated handles the document updated event, retrieving the document
 // root for the root frame.
 func (h *TargetHandler) documentUpdated(ctxt context.Context) {
@@ -318,7 +696,7 @@ func (h *TargetHandler) processCommand(cmd *cdproto.Message) error {
 
 	h.debugf("<- %s", string(buf))
 
-	return h.conn.Write(buf)
+	return h.write(buf)
 }
 
 // emptyObj is an empty JSON object message.
@@ -440,8 +818,7 @@ func (h *TargetHandler) SetActive(ctxt context.Context, id cdp.FrameID) error {
 
 // WaitFrame waits for a frame to be loaded using the provided context.
 func (h *TargetHandler) WaitFrame(ctxt context.Context, id cdp.FrameID) (*cdp.Frame, error) {
-	// TODO: fix this
-	timeout := time.After(10 * time.Second)
+	timeout := h.waitTimeoutChan()
 
 	for {
 		select {
@@ -472,10 +849,22 @@ func (h *TargetHandler) WaitFrame(ctxt context.Context, id cdp.FrameID) (*cdp.Fr
 	}
 }
 
+// waitTimeoutChan returns a channel that fires after the handler's wait
+// timeout, or a nil channel when the wait timeout is disabled.
+func (h *TargetHandler) waitTimeoutChan() <-chan time.Time {
+	h.RLock()
+	d := h.waitTimeout
+	h.RUnlock()
+
+	if d == 0 {
+		return nil
+	}
+	return time.After(d)
+}
+
 // WaitNode waits for a node to be loaded using the provided context.
 func (h *TargetHandler) WaitNode(ctxt context.Context, f *cdp.Frame, id cdp.NodeID) (*cdp.Node, error) {
-	// TODO: fix this
-	timeout := time.After(10 * time.Second)
+	timeout := h.waitTimeoutChan()
 
 	for {
 		select {
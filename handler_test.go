@@ -0,0 +1,81 @@
+package chromedp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{
+		debugf: func(string, ...interface{}) {},
+	}
+
+	all, methods := make(chan interface{}, 2), make(chan interface{}, 2)
+	cancelAll := h.Listen(func(ev interface{}) {
+		all <- ev
+	})
+	defer cancelAll()
+	cancelMethods := h.Listen(func(ev interface{}) {
+		methods <- ev
+	}, cdproto.EventPageLoadEventFired)
+
+	h.dispatch(cdproto.EventRuntimeExecutionContextsCleared, new(runtime.EventExecutionContextsCleared))
+	h.dispatch(cdproto.EventPageLoadEventFired, new(page.EventLoadEventFired))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+			t.Fatalf("expected event %d to be delivered to listener", i)
+		}
+	}
+
+	select {
+	case ev := <-methods:
+		if _, ok := ev.(*page.EventLoadEventFired); !ok {
+			t.Errorf("expected *page.EventLoadEventFired, got: %T", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected event to be delivered to method listener")
+	}
+
+	cancelMethods()
+	if n := len(h.listeners); n != 1 {
+		t.Errorf("expected 1 listener after cancel, got: %d", n)
+	}
+}
+
+func TestListenDropsEvents(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{
+		debugf: func(string, ...interface{}) {},
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+	cancel := h.Listen(func(ev interface{}) {
+		<-block
+	})
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < DefaultListenerQueueSize+10; i++ {
+			h.dispatch(cdproto.EventPageLoadEventFired, new(page.EventLoadEventFired))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected dispatch not to block on a slow listener")
+	}
+}
@@ -0,0 +1,486 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+)
+
+// HAROption is a HAR recorder option.
+type HAROption func(*harRecorder)
+
+// WithHARBodies is a HAR recorder option to include the response bodies in
+// the recorded entries, retrieving each body with network.GetResponseBody
+// when the response has finished loading.
+func WithHARBodies() HAROption {
+	return func(r *harRecorder) {
+		r.bodies = true
+	}
+}
+
+// harRecorder records the network activity of a target as HAR entries.
+type harRecorder struct {
+	// bodies toggles retrieving response bodies.
+	bodies bool
+
+	// entries are the recorded entries, in request order.
+	entries []*harEntry
+
+	// requests is the map of request ids to their pending entries.
+	requests map[network.RequestID]*harEntry
+
+	// wg tracks the response body retrievals.
+	wg sync.WaitGroup
+
+	// stopped is set when the recording has stopped, after which no events
+	// are recorded.
+	stopped bool
+
+	mu sync.Mutex
+}
+
+// harEntry is a recorded request.
+type harEntry struct {
+	req     *network.EventRequestWillBeSent
+	res     *network.Response
+	resTime *cdp.MonotonicTime
+	endTime *cdp.MonotonicTime
+	size    float64
+	decoded int64
+	err     string
+	body    []byte
+}
+
+// newHARRecorder creates a HAR recorder.
+func newHARRecorder(opts ...HAROption) *harRecorder {
+	r := &harRecorder{
+		requests: make(map[network.RequestID]*harEntry),
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// event records a network event, retrieving the response body using h when
+// the response has finished loading.
+func (r *harRecorder) event(ctxt context.Context, h cdp.Executor, ev interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// a redirect completes the previous request with the same id
+		if e.RedirectResponse != nil {
+			if prev, ok := r.requests[e.RequestID]; ok {
+				prev.res, prev.resTime, prev.endTime = e.RedirectResponse, e.Timestamp, e.Timestamp
+			}
+		}
+
+		x := &harEntry{req: e}
+		r.entries = append(r.entries, x)
+		r.requests[e.RequestID] = x
+
+	case *network.EventResponseReceived:
+		if x, ok := r.requests[e.RequestID]; ok {
+			x.res, x.resTime = e.Response, e.Timestamp
+		}
+
+	case *network.EventDataReceived:
+		if x, ok := r.requests[e.RequestID]; ok {
+			x.decoded += e.DataLength
+		}
+
+	case *network.EventLoadingFinished:
+		x, ok := r.requests[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(r.requests, e.RequestID)
+		x.endTime, x.size = e.Timestamp, e.EncodedDataLength
+
+		if r.bodies {
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+
+				body, err := network.GetResponseBody(e.RequestID).Do(ctxt, h)
+				if err != nil {
+					return
+				}
+
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				x.body = body
+			}()
+		}
+
+	case *network.EventLoadingFailed:
+		x, ok := r.requests[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(r.requests, e.RequestID)
+		x.endTime, x.err = e.Timestamp, e.ErrorText
+	}
+}
+
+// har stops the recording, and builds the HAR log of the recorded entries,
+// waiting for any pending response body retrievals.
+func (r *harRecorder) har(creator, browser *har.Creator) *har.HAR {
+	// stop recording before waiting, so that no body retrievals are added
+	// while waiting
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*har.Entry, 0, len(r.entries))
+	for _, x := range r.entries {
+		entries = append(entries, x.entry())
+	}
+
+	return &har.HAR{
+		Log: &har.Log{
+			Version: "1.2",
+			Creator: creator,
+			Browser: browser,
+			Entries: entries,
+		},
+	}
+}
+
+// entry converts the recorded request to a HAR entry.
+func (x *harEntry) entry() *har.Entry {
+	req := x.req.Request
+
+	reqHeaders := req.Headers
+	if x.res != nil && len(x.res.RequestHeaders) != 0 {
+		reqHeaders = x.res.RequestHeaders
+	}
+	reqPairs, reqHeader := harHeaders(reqHeaders)
+
+	httpVersion := ""
+	if x.res != nil {
+		httpVersion = harHTTPVersion(x.res.Protocol)
+	}
+
+	e := &har.Entry{
+		StartedDateTime: x.req.WallTime.Time().UTC().Format(time.RFC3339Nano),
+		Request: &har.Request{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: httpVersion,
+			Cookies:     harCookies((&http.Request{Header: reqHeader}).Cookies()),
+			Headers:     reqPairs,
+			QueryString: harQueryString(req.URL),
+			HeadersSize: -1,
+			BodySize:    int64(len(req.PostData)),
+		},
+		Response: &har.Response{
+			Cookies: []*har.Cookie{},
+			Headers: []*har.NameValuePair{},
+			Content: &har.Content{
+				Size:     -1,
+				MimeType: "x-unknown",
+			},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache:   new(har.Cache),
+		Timings: x.timings(),
+	}
+
+	if req.PostData != "" {
+		e.Request.PostData = &har.PostData{
+			MimeType: reqHeader.Get("Content-Type"),
+			Params:   []*har.Param{},
+			Text:     req.PostData,
+		}
+	}
+
+	if x.res != nil {
+		resPairs, resHeader := harHeaders(x.res.Headers)
+
+		e.Response.Status = x.res.Status
+		e.Response.StatusText = x.res.StatusText
+		e.Response.HTTPVersion = httpVersion
+		e.Response.Cookies = harCookies((&http.Response{Header: resHeader}).Cookies())
+		e.Response.Headers = resPairs
+		e.Response.RedirectURL = resHeader.Get("Location")
+		e.Response.Content.MimeType = x.res.MimeType
+		// the decoded size, as retrieved bodies are decoded
+		e.Response.Content.Size = x.decoded
+		if n := int64(len(x.body)); n > x.decoded {
+			e.Response.Content.Size = n
+		}
+		if x.size != 0 {
+			e.Response.BodySize = int64(x.size)
+		}
+		e.ServerIPAddress = x.res.RemoteIPAddress
+		if x.res.ConnectionID != 0 {
+			e.Connection = fmt.Sprintf("%.0f", x.res.ConnectionID)
+		}
+	}
+
+	switch {
+	case len(x.body) == 0:
+	case utf8.Valid(x.body):
+		e.Response.Content.Text = string(x.body)
+	default:
+		e.Response.Content.Text = base64.StdEncoding.EncodeToString(x.body)
+		e.Response.Content.Encoding = "base64"
+	}
+
+	if x.err != "" {
+		e.Response.Comment = x.err
+	}
+
+	for _, v := range []float64{e.Timings.Blocked, e.Timings.DNS, e.Timings.Connect, e.Timings.Send, e.Timings.Wait, e.Timings.Receive} {
+		if v > 0 {
+			e.Time += v
+		}
+	}
+
+	return e
+}
+
+// timings builds the HAR timings of the recorded request, in milliseconds.
+func (x *harEntry) timings() *har.Timings {
+	t := &har.Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1}
+
+	start := monotonicSeconds(x.req.Timestamp)
+	end := start
+	if x.endTime != nil {
+		end = monotonicSeconds(x.endTime)
+	}
+
+	if x.res == nil || x.res.Timing == nil {
+		// no detailed timing is available (ie, cached or failed requests)
+		headers := end
+		if x.resTime != nil {
+			headers = monotonicSeconds(x.resTime)
+		}
+		t.Wait = nonNegative((headers - start) * 1000)
+		t.Receive = nonNegative((end - headers) * 1000)
+		return t
+	}
+
+	rt := x.res.Timing
+	switch {
+	case rt.DNSStart >= 0:
+		t.Blocked = rt.DNSStart
+	case rt.ConnectStart >= 0:
+		t.Blocked = rt.ConnectStart
+	default:
+		t.Blocked = rt.SendStart
+	}
+	if rt.DNSStart >= 0 {
+		t.DNS = rt.DNSEnd - rt.DNSStart
+	}
+	if rt.ConnectStart >= 0 {
+		t.Connect = rt.ConnectEnd - rt.ConnectStart
+	}
+	if rt.SslStart >= 0 {
+		t.Ssl = rt.SslEnd - rt.SslStart
+	}
+	t.Send = nonNegative(rt.SendEnd - rt.SendStart)
+	t.Wait = nonNegative(rt.ReceiveHeadersEnd - rt.SendEnd)
+	t.Receive = nonNegative((end-rt.RequestTime)*1000 - rt.ReceiveHeadersEnd)
+
+	return t
+}
+
+// monotonicSeconds returns the monotonic time as seconds since the monotonic
+// time epoch.
+func monotonicSeconds(t *cdp.MonotonicTime) float64 {
+	if t == nil {
+		return 0
+	}
+	return float64(t.Time().Sub(*cdp.MonotonicTimeEpoch)) / float64(time.Second)
+}
+
+// nonNegative returns v, or 0 when v is negative.
+func nonNegative(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// harCreatorVersion returns the version of the chromedp module used by the
+// binary, or "(devel)" when the version is not available.
+func harCreatorVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, m := range append([]*debug.Module{&bi.Main}, bi.Deps...) {
+			if m.Path == "github.com/chromedp/chromedp" && m.Version != "" {
+				return m.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// harHTTPVersion converts the protocol reported by chrome to a HTTP version.
+func harHTTPVersion(protocol string) string {
+	switch protocol {
+	case "":
+		return ""
+	case "h2":
+		return "HTTP/2.0"
+	}
+	return strings.ToUpper(protocol)
+}
+
+// harHeaders converts network headers to HAR name/value pairs, sorted by
+// name, and to a http.Header.
+func harHeaders(headers network.Headers) ([]*har.NameValuePair, http.Header) {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	pairs, header := []*har.NameValuePair{}, make(http.Header)
+	for _, k := range names {
+		// chrome joins multiple header values with newlines
+		for _, v := range strings.Split(fmt.Sprint(headers[k]), "\n") {
+			pairs = append(pairs, &har.NameValuePair{Name: k, Value: v})
+			header.Add(k, v)
+		}
+	}
+
+	return pairs, header
+}
+
+// harCookies converts http cookies to HAR cookies.
+func harCookies(cookies []*http.Cookie) []*har.Cookie {
+	v := make([]*har.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		hc := &har.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.UTC().Format(time.RFC3339)
+		}
+		v = append(v, hc)
+	}
+	return v
+}
+
+// harQueryString converts the query string of urlstr to HAR name/value
+// pairs.
+func harQueryString(urlstr string) []*har.NameValuePair {
+	pairs := []*har.NameValuePair{}
+
+	u, err := url.Parse(urlstr)
+	if err != nil {
+		return pairs
+	}
+
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+
+		i := strings.IndexByte(kv, '=')
+		if i == -1 {
+			i = len(kv)
+			kv += "="
+		}
+
+		k, err := url.QueryUnescape(kv[:i])
+		if err != nil {
+			k = kv[:i]
+		}
+		v, err := url.QueryUnescape(kv[i+1:])
+		if err != nil {
+			v = kv[i+1:]
+		}
+		pairs = append(pairs, &har.NameValuePair{Name: k, Value: v})
+	}
+
+	return pairs
+}
+
+// StartHAR is an action that starts recording the network activity of the
+// current target, replacing any previously started recording.
+func StartHAR(opts ...HAROption) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		th.Lock()
+		defer th.Unlock()
+
+		th.har = newHARRecorder(opts...)
+
+		return nil
+	})
+}
+
+// StopHAR is an action that stops recording the network activity of the
+// current target, writing the recorded activity to w as a HAR 1.2 log.
+func StopHAR(w io.Writer) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		th.Lock()
+		r := th.har
+		th.har = nil
+		th.Unlock()
+
+		if r == nil {
+			return ErrHARNotStarted
+		}
+
+		var b *har.Creator
+		_, product, _, _, _, err := browser.GetVersion().Do(ctxt, th)
+		if err == nil {
+			// HAR 1.2 requires the version
+			b = &har.Creator{Name: product, Version: "unknown"}
+			if i := strings.IndexByte(product, '/'); i != -1 {
+				b.Name, b.Version = product[:i], product[i+1:]
+			}
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.har(&har.Creator{Name: "chromedp", Version: harCreatorVersion()}, b))
+	})
+}
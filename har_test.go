@@ -0,0 +1,173 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestHARRecorder(t *testing.T) {
+	t.Parallel()
+
+	mono := func(ms int) *cdp.MonotonicTime {
+		v := cdp.MonotonicTime(cdp.MonotonicTimeEpoch.Add(10*time.Second + time.Duration(ms)*time.Millisecond))
+		return &v
+	}
+	wall := cdp.TimeSinceEpoch(time.Date(2019, 2, 1, 10, 0, 0, 0, time.UTC))
+
+	r := newHARRecorder()
+	for _, ev := range []interface{}{
+		&network.EventRequestWillBeSent{
+			RequestID: "1",
+			Request: &network.Request{
+				URL:     "http://localhost/a?x=1&y=two%20words",
+				Method:  "GET",
+				Headers: network.Headers{"Cookie": "session=abc"},
+			},
+			Timestamp: mono(0),
+			WallTime:  &wall,
+		},
+		&network.EventRequestWillBeSent{
+			RequestID: "1",
+			Request:   &network.Request{URL: "http://localhost/b", Method: "GET"},
+			RedirectResponse: &network.Response{
+				Status:     http.StatusFound,
+				StatusText: "Found",
+				Headers:    network.Headers{"Location": "/b"},
+				Protocol:   "http/1.1",
+			},
+			Timestamp: mono(10),
+			WallTime:  &wall,
+		},
+		&network.EventResponseReceived{
+			RequestID: "1",
+			Response: &network.Response{
+				Status:     http.StatusOK,
+				StatusText: "OK",
+				Headers:    network.Headers{"Set-Cookie": "a=1\nb=2"},
+				MimeType:   "text/html",
+				Protocol:   "h2",
+			},
+			Timestamp: mono(30),
+		},
+		&network.EventDataReceived{RequestID: "1", Timestamp: mono(40), DataLength: 150, EncodedDataLength: 80},
+		&network.EventLoadingFinished{RequestID: "1", Timestamp: mono(50), EncodedDataLength: 100},
+		&network.EventRequestWillBeSent{
+			RequestID: "2",
+			Request:   &network.Request{URL: "http://localhost/c", Method: "POST", PostData: "z=1"},
+			Timestamp: mono(60),
+			WallTime:  &wall,
+		},
+		&network.EventLoadingFailed{RequestID: "2", Timestamp: mono(70), ErrorText: "net::ERR_FAILED"},
+	} {
+		r.event(context.Background(), nil, ev)
+	}
+
+	h := r.har(&har.Creator{Name: "chromedp"}, nil)
+	if h.Log.Version != "1.2" {
+		t.Errorf("expected version 1.2, got: %q", h.Log.Version)
+	}
+	if len(h.Log.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got: %d", len(h.Log.Entries))
+	}
+
+	e := h.Log.Entries[0]
+	if e.Response.Status != http.StatusFound || e.Response.RedirectURL != "/b" {
+		t.Errorf("expected redirect to /b, got: %d %q", e.Response.Status, e.Response.RedirectURL)
+	}
+	if len(e.Request.QueryString) != 2 || e.Request.QueryString[1].Value != "two words" {
+		t.Errorf("expected query string, got: %v", e.Request.QueryString)
+	}
+	if len(e.Request.Cookies) != 1 || e.Request.Cookies[0].Value != "abc" {
+		t.Errorf("expected request cookie, got: %v", e.Request.Cookies)
+	}
+	if exp := "2019-02-01T10:00:00Z"; e.StartedDateTime != exp {
+		t.Errorf("expected %s, got: %s", exp, e.StartedDateTime)
+	}
+
+	e = h.Log.Entries[1]
+	if e.Response.Status != http.StatusOK || e.Response.HTTPVersion != "HTTP/2.0" {
+		t.Errorf("expected 200 HTTP/2.0, got: %d %s", e.Response.Status, e.Response.HTTPVersion)
+	}
+	if len(e.Response.Cookies) != 2 {
+		t.Errorf("expected 2 response cookies, got: %v", e.Response.Cookies)
+	}
+	if e.Response.BodySize != 100 {
+		t.Errorf("expected body size 100, got: %d", e.Response.BodySize)
+	}
+	if e.Response.Content.Size != 150 {
+		t.Errorf("expected content size 150, got: %d", e.Response.Content.Size)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	if !near(e.Timings.Wait, 20) || !near(e.Timings.Receive, 20) || !near(e.Time, 40) {
+		t.Errorf("expected wait 20, receive 20, time 40, got: %f %f %f", e.Timings.Wait, e.Timings.Receive, e.Time)
+	}
+
+	e = h.Log.Entries[2]
+	if e.Response.Comment != "net::ERR_FAILED" {
+		t.Errorf("expected error comment, got: %q", e.Response.Comment)
+	}
+	if e.Response.Content.Size != -1 {
+		t.Errorf("expected unknown content size, got: %d", e.Response.Content.Size)
+	}
+	if e.Request.PostData == nil || e.Request.PostData.Text != "z=1" {
+		t.Errorf("expected post data, got: %v", e.Request.PostData)
+	}
+
+	// events after stopping are not recorded
+	r.event(context.Background(), nil, &network.EventRequestWillBeSent{
+		RequestID: "3",
+		Request:   &network.Request{URL: "http://localhost/d", Method: "GET"},
+		Timestamp: mono(80),
+		WallTime:  &wall,
+	})
+	if n := len(r.har(&har.Creator{Name: "chromedp"}, nil).Log.Entries); n != 3 {
+		t.Errorf("expected 3 entries, got: %d", n)
+	}
+}
+
+func TestStopHAR(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>har</body></html>`)
+	}))
+	defer s.Close()
+
+	var buf bytes.Buffer
+	err := c.Run(defaultContext, Tasks{
+		StartHAR(WithHARBodies()),
+		Navigate(s.URL),
+		StopHAR(&buf),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v har.HAR
+	if err = json.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Log.Entries) == 0 {
+		t.Fatal("expected HAR entries")
+	}
+	if e := v.Log.Entries[0]; e.Request.URL != s.URL+"/" || e.Response.Status != http.StatusOK {
+		t.Errorf("expected 200 %s/, got: %d %s", s.URL, e.Response.Status, e.Request.URL)
+	}
+	if v.Log.Creator.Version == "" || v.Log.Browser == nil || v.Log.Browser.Version == "" {
+		t.Errorf("expected creator and browser versions, got: %v %v", v.Log.Creator, v.Log.Browser)
+	}
+}
@@ -77,10 +77,65 @@ func MouseClickNode(n *cdp.Node, opts ...MouseOption) Action {
 		x /= int64(c / 2)
 		y /= int64(c / 2)
 
-		return MouseClickXY(x, y, opts...).Do(ctxt, h)
+		// translate to the top level target's viewport
+		root, ox, oy, err := frameOffset(ctxt, h)
+		if err != nil {
+			return err
+		}
+
+		return MouseClickXY(x+ox, y+oy, opts...).Do(ctxt, root)
 	})
 }
 
+// inputExecutor returns the executor that input events for h are dispatched
+// to. For handlers of out-of-process iframes, this is the handler of the top
+// level target.
+func inputExecutor(h cdp.Executor) cdp.Executor {
+	th, ok := h.(*TargetHandler)
+	if !ok {
+		return h
+	}
+
+	for th.parent != nil {
+		th = th.parent
+	}
+
+	return th
+}
+
+// frameOffset returns the executor that input events for h are dispatched to,
+// and the offset of h's viewport in the executor's viewport. For handlers of
+// out-of-process iframes, the offset is the position of the iframes' owner
+// elements.
+func frameOffset(ctxt context.Context, h cdp.Executor) (cdp.Executor, int64, int64, error) {
+	th, ok := h.(*TargetHandler)
+	if !ok {
+		return h, 0, 0, nil
+	}
+
+	var x, y int64
+	for th.parent != nil {
+		id, _, err := dom.GetFrameOwner(cdp.FrameID(th.Target().GetID())).Do(ctxt, th.parent)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		box, err := dom.GetBoxModel().WithBackendNodeID(id).Do(ctxt, th.parent)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if len(box.Content) < 2 {
+			return nil, 0, 0, ErrInvalidDimensions
+		}
+
+		x += int64(box.Content[0])
+		y += int64(box.Content[1])
+		th = th.parent
+	}
+
+	return th, x, y, nil
+}
+
 // MouseOption is a mouse action option.
 type MouseOption func(*input.DispatchMouseEventParams) *input.DispatchMouseEventParams
 
@@ -149,6 +204,10 @@ func KeyAction(keys string, opts ...KeyOption) Action {
 	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
 		var err error
 
+		// key events are dispatched to the focused frame via the top level
+		// target
+		h = inputExecutor(h)
+
 		for _, r := range keys {
 			for _, k := range kb.Encode(r) {
 				err = k.Do(ctxt, h)
@@ -0,0 +1,270 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+// InterceptFunc is a func that handles an intercepted request.
+//
+// The func can continue, fail, or fulfill the request. Requests not handled
+// by the func are continued unmodified.
+type InterceptFunc func(context.Context, *InterceptedRequest) error
+
+// interceptor is a registered request interceptor.
+type interceptor struct {
+	f        InterceptFunc
+	patterns []*fetch.RequestPattern
+}
+
+// matches determines if the paused request matches any of the interceptor's
+// patterns.
+func (i *interceptor) matches(ev *fetch.EventRequestPaused) bool {
+	if len(i.patterns) == 0 {
+		return true
+	}
+
+	for _, p := range i.patterns {
+		if p.ResourceType != "" && p.ResourceType != ev.ResourceType {
+			continue
+		}
+		if p.URLPattern == "" || matchURLPattern(p.URLPattern, ev.Request.URL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InterceptedRequest is a request paused by a request interception.
+type InterceptedRequest struct {
+	*fetch.EventRequestPaused
+
+	h    cdp.Executor
+	done bool
+	mu   sync.Mutex
+}
+
+// resolve executes the action resolving the request, when the request has not
+// already been resolved.
+func (r *InterceptedRequest) resolve(ctxt context.Context, a Action) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return ErrRequestResolved
+	}
+	r.done = true
+
+	return a.Do(ctxt, r.h)
+}
+
+// Continue continues the request unmodified.
+func (r *InterceptedRequest) Continue(ctxt context.Context) error {
+	return r.resolve(ctxt, fetch.ContinueRequest(r.RequestID))
+}
+
+// ContinueWithHeaders continues the request, replacing the request headers
+// with headers.
+func (r *InterceptedRequest) ContinueWithHeaders(ctxt context.Context, headers http.Header) error {
+	return r.resolve(ctxt, fetch.ContinueRequest(r.RequestID).WithHeaders(headerEntries(headers)))
+}
+
+// Fail fails the request with the specified network error reason (ie,
+// network.ErrorReasonFailed, network.ErrorReasonAborted, etc).
+func (r *InterceptedRequest) Fail(ctxt context.Context, reason network.ErrorReason) error {
+	return r.resolve(ctxt, fetch.FailRequest(r.RequestID, reason))
+}
+
+// Fulfill fulfills the request with a response having the specified status
+// code, headers, and body, without sending the request to the network.
+func (r *InterceptedRequest) Fulfill(ctxt context.Context, status int, headers http.Header, body []byte) error {
+	p := fetch.FulfillRequest(r.RequestID, int64(status), headerEntries(headers))
+	if len(body) != 0 {
+		p = p.WithBody(base64.StdEncoding.EncodeToString(body))
+	}
+	return r.resolve(ctxt, p)
+}
+
+// headerEntries converts headers to fetch header entries, sorted by name.
+func headerEntries(headers http.Header) []*fetch.HeaderEntry {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for _, k := range names {
+		for _, v := range headers[k] {
+			entries = append(entries, &fetch.HeaderEntry{Name: k, Value: v})
+		}
+	}
+	return entries
+}
+
+// Intercept intercepts the requests of the target matching any of the
+// patterns (or all requests when no patterns are specified), passing each
+// intercepted request to f. When several interceptors match a request, the
+// most recently added interceptor handles the request.
+//
+// The returned func removes the interceptor, disabling request interception
+// when no interceptors remain.
+func (h *TargetHandler) Intercept(ctxt context.Context, f InterceptFunc, patterns ...*fetch.RequestPattern) (func(context.Context) error, error) {
+	i := &interceptor{f: f, patterns: patterns}
+
+	h.irw.Lock()
+	defer h.irw.Unlock()
+
+	h.interceptors = append(h.interceptors, i)
+	if err := h.enableFetch(ctxt); err != nil {
+		h.interceptors = h.interceptors[:len(h.interceptors)-1]
+		return nil, err
+	}
+
+	return func(ctxt context.Context) error {
+		h.irw.Lock()
+		defer h.irw.Unlock()
+
+		for j, x := range h.interceptors {
+			if x == i {
+				h.interceptors = append(h.interceptors[:j:j], h.interceptors[j+1:]...)
+				return h.enableFetch(ctxt)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// enableFetch enables the fetch domain with the patterns of all registered
+// interceptors, or disables the fetch domain when there are no registered
+// interceptors.
+//
+// Note: the caller must hold the interceptor lock.
+func (h *TargetHandler) enableFetch(ctxt context.Context) error {
+	if len(h.interceptors) == 0 {
+		return fetch.Disable().Do(ctxt, h)
+	}
+
+	var patterns []*fetch.RequestPattern
+	for _, i := range h.interceptors {
+		if len(i.patterns) == 0 {
+			patterns = []*fetch.RequestPattern{{URLPattern: "*"}}
+			break
+		}
+		patterns = append(patterns, i.patterns...)
+	}
+
+	return fetch.Enable().WithPatterns(patterns).Do(ctxt, h)
+}
+
+// requestPaused passes a paused request to the most recently added matching
+// interceptor, continuing the request if it is not handled.
+func (h *TargetHandler) requestPaused(ctxt context.Context, ev *fetch.EventRequestPaused) {
+	var f InterceptFunc
+	h.irw.Lock()
+	for j := len(h.interceptors) - 1; j >= 0; j-- {
+		if h.interceptors[j].matches(ev) {
+			f = h.interceptors[j].f
+			break
+		}
+	}
+	h.irw.Unlock()
+
+	r := &InterceptedRequest{EventRequestPaused: ev, h: h}
+	if f != nil {
+		if err := f(ctxt, r); err != nil {
+			h.errf("could not handle intercepted request %s: %v", ev.Request.URL, err)
+		}
+	}
+
+	if err := r.Continue(ctxt); err != nil && err != ErrRequestResolved {
+		h.errf("could not continue intercepted request %s: %v", ev.Request.URL, err)
+	}
+}
+
+// matchURLPattern determines if s matches the fetch URL pattern, where '*'
+// matches zero or more characters, '?' matches exactly one character, and
+// '\' escapes the next character.
+func matchURLPattern(pattern, s string) bool {
+	for len(pattern) != 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchURLPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern, s = pattern[1:], s[1:]
+	}
+
+	return len(s) == 0
+}
+
+// Intercept is an action that intercepts the requests of the current target
+// matching any of the patterns (or all requests when no patterns are
+// specified), passing each intercepted request to f.
+//
+// The interceptor remains installed until all interceptors are removed with
+// StopIntercept. Use CDP.Intercept or TargetHandler.Intercept to remove a
+// single interceptor.
+//
+// For example, to stub a backend API with a canned response:
+//
+//	Intercept(func(ctxt context.Context, r *InterceptedRequest) error {
+//		return r.Fulfill(ctxt, 200, http.Header{"Content-Type": {"application/json"}}, []byte(`{"ok":true}`))
+//	}, &fetch.RequestPattern{URLPattern: "*/api/*"})
+func Intercept(f InterceptFunc, patterns ...*fetch.RequestPattern) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		_, err := th.Intercept(ctxt, f, patterns...)
+		return err
+	})
+}
+
+// StopIntercept is an action that removes all request interceptors of the
+// current target, disabling request interception.
+func StopIntercept() Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		th.irw.Lock()
+		defer th.irw.Unlock()
+
+		th.interceptors = nil
+		return th.enableFetch(ctxt)
+	})
+}
@@ -0,0 +1,137 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestMatchURLPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern, s string
+		exp        bool
+	}{
+		{"*", "", true},
+		{"*", "http://localhost/", true},
+		{"*/api/*", "http://localhost/api/users", true},
+		{"*/api/*", "http://localhost/static/app.js", false},
+		{"http://localhost/?", "http://localhost/a", true},
+		{"http://localhost/?", "http://localhost/", false},
+		{"http://localhost/?", "http://localhost/ab", false},
+		{"*.js", "http://localhost/app.js", true},
+		{"*.js", "http://localhost/app.json", false},
+		{`*\*`, "http://localhost/*", true},
+		{`*\*`, "http://localhost/a", false},
+		{`*\?`, "http://localhost/?", true},
+	}
+
+	for i, test := range tests {
+		if got := matchURLPattern(test.pattern, test.s); got != test.exp {
+			t.Errorf("test %d %q %q expected %t, got: %t", i, test.pattern, test.s, test.exp, got)
+		}
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user":
+			fmt.Fprint(w, `{"name":"real"}`)
+		default:
+			fmt.Fprint(w, `<html><body><div id="user"></div><div id="status"></div><script>
+fetch('/api/user').then(r => r.json()).then(v => document.getElementById('user').textContent = v.name);
+fetch('/api/status').then(() => 'up', () => 'down').then(v => document.getElementById('status').textContent = v);
+</script></body></html>`)
+		}
+	}))
+	defer s.Close()
+
+	err := c.Run(defaultContext, Tasks{
+		Intercept(func(ctxt context.Context, r *InterceptedRequest) error {
+			return r.Fulfill(ctxt, http.StatusOK, http.Header{"Content-Type": {"application/json"}}, []byte(`{"name":"stub"}`))
+		}, &fetch.RequestPattern{URLPattern: "*/api/user"}),
+		Intercept(func(ctxt context.Context, r *InterceptedRequest) error {
+			return r.Fail(ctxt, network.ErrorReasonFailed)
+		}, &fetch.RequestPattern{URLPattern: "*/api/status"}),
+		Navigate(s.URL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user, status string
+	err = c.Run(defaultContext, Tasks{
+		WaitVisible(`//div[@id="user" and text()]`, BySearch),
+		Text("#user", &user, ByID),
+		WaitVisible(`//div[@id="status" and text()]`, BySearch),
+		Text("#status", &status, ByID),
+		StopIntercept(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "stub" {
+		t.Errorf("expected stub, got: %q", user)
+	}
+	if status != "down" {
+		t.Errorf("expected down, got: %q", status)
+	}
+}
+
+func TestInterceptRemove(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div id="source">real</div></body></html>`)
+	}))
+	defer s.Close()
+
+	remove, err := c.CDP().Intercept(defaultContext, func(ctxt context.Context, r *InterceptedRequest) error {
+		return r.Fulfill(ctxt, http.StatusOK, http.Header{"Content-Type": {"text/html"}}, []byte(`<html><body><div id="source">stub</div></body></html>`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var source string
+	err = c.Run(defaultContext, Tasks{
+		Navigate(s.URL),
+		Text("#source", &source, ByID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != "stub" {
+		t.Errorf("expected stub, got: %q", source)
+	}
+
+	if err = remove(defaultContext); err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Run(defaultContext, Tasks{
+		Navigate(s.URL),
+		Text("#source", &source, ByID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != "real" {
+		t.Errorf("expected real, got: %q", source)
+	}
+}
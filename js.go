@@ -1,23 +1,24 @@
 package chromedp
 
 const (
-	// textJS is a javascript snippet that returns the concatenated textContent
-	// of all visible (ie, offsetParent !== null) children.
-	textJS = `(function(a) {
+	// textJS is a javascript function that returns the concatenated
+	// textContent of all visible (ie, offsetParent !== null) children of the
+	// node it is called on.
+	textJS = `function() {
 		var s = '';
-		for (var i = 0; i < a.length; i++) {
-			if (a[i].offsetParent !== null) {
-				s += a[i].textContent;
+		for (var i = 0; i < this.childNodes.length; i++) {
+			if (this.childNodes[i].offsetParent !== null) {
+				s += this.childNodes[i].textContent;
 			}
 		}
 		return s;
-	})($x('%s/node()'))`
+	}`
 
-	// blurJS is a javscript snippet that blurs the specified element.
-	blurJS = `(function(a) {
-		a[0].blur();
+	// blurJS is a javascript function that blurs the element it is called on.
+	blurJS = `function() {
+		this.blur();
 		return true;
-	})($x('%s'))`
+	}`
 
 	// scrollJS is a javascript snippet that scrolls the window to the
 	// specified x, y coordinates and then returns the actual window x/y after
@@ -27,55 +28,214 @@ const (
 		return [window.scrollX, window.scrollY];
 	})(%d, %d)`
 
-	// scrollIntoViewJS is a javascript snippet that scrolls the specified node
-	// into the window's viewport (if needed), returning the actual window x/y
-	// after execution.
-	scrollIntoViewJS = `(function(a) {
-		a[0].scrollIntoViewIfNeeded(true);
+	// scrollIntoViewJS is a javascript function that scrolls the node it is
+	// called on into the window's viewport (if needed), returning the actual
+	// window x/y after execution.
+	scrollIntoViewJS = `function() {
+		this.scrollIntoViewIfNeeded(true);
 		return [window.scrollX, window.scrollY];
-	})($x('%s'))`
+	}`
 
-	// submitJS is a javascript snippet that will call the containing form's
-	// submit function, returning true or false if the call was successful.
-	submitJS = `(function(a) {
-		if (a[0].nodeName === 'FORM') {
-			a[0].submit();
+	// submitJS is a javascript function that will call the submit function of
+	// the form containing the node it is called on, returning true or false if
+	// the call was successful.
+	submitJS = `function() {
+		if (this.nodeName === 'FORM') {
+			this.submit();
 			return true;
-		} else if (a[0].form !== null) {
-			a[0].form.submit();
+		} else if (this.form !== null) {
+			this.form.submit();
 			return true;
 		}
 		return false;
-	})($x('%s'))`
+	}`
 
-	// resetJS is a javascript snippet that will call the containing form's
-	// reset function, returning true or false if the call was successful.
-	resetJS = `(function(a) {
-		if (a[0].nodeName === 'FORM') {
-			a[0].reset();
+	// resetJS is a javascript function that will call the reset function of
+	// the form containing the node it is called on, returning true or false if
+	// the call was successful.
+	resetJS = `function() {
+		if (this.nodeName === 'FORM') {
+			this.reset();
 			return true;
-		} else if (a[0].form !== null) {
-			a[0].form.reset();
+		} else if (this.form !== null) {
+			this.form.reset();
 			return true;
 		}
 		return false;
-	})($x('%s'))`
+	}`
 
-	// attributeJS is a javascript snippet that returns the attribute of a specified
-	// node.
-	attributeJS = `(function(a, n) {
-		return a[0][n];
-	})($x('%s'), '%s')`
+	// attributeJS is a javascript function that returns the named javascript
+	// attribute of the node it is called on.
+	attributeJS = `function(n) {
+		return this[n];
+	}`
 
-	// setAttributeJS is a javascript snippet that sets the value of the specified
-	// node, and returns the value.
-	setAttributeJS = `(function(a, n, v) {
-		return a[0][n] = v;
-	})($x('%s'), '%s', '%s')`
+	// setAttributeJS is a javascript function that sets the named javascript
+	// attribute of the node it is called on to the value, and returns the
+	// value.
+	setAttributeJS = `function(n, v) {
+		return this[n] = v;
+	}`
 
-	// visibleJS is a javascript snippet that returns true or false depending
-	// on if the specified node's offsetParent is not null.
-	visibleJS = `(function(a) {
-		return a[0].offsetParent !== null;
-	})($x('%s'))`
+	// visibleJS is a javascript function that returns true or false depending
+	// on if the offsetParent of the node it is called on is not null.
+	visibleJS = `function() {
+		return this.offsetParent !== null;
+	}`
+
+	// originLoadedJS is a javascript snippet that returns whether the page
+	// has loaded, and has the specified origin.
+	originLoadedJS = `document.readyState === 'complete' && location.origin === %s`
+
+	// restoreIndexedDBJS is a javascript snippet that recreates the specified
+	// IndexedDB databases (deleting any existing database with the same name),
+	// and puts the databases' records, resolving when all databases have been
+	// restored. The databases are only restored when the page's origin is
+	// the expected origin.
+	restoreIndexedDBJS = `(function(origin, dbs) {
+		if (location.origin !== origin) {
+			return Promise.reject(new Error('expected origin ' + origin + ', got ' + location.origin));
+		}
+		return Promise.all(dbs.map(function(db) {
+			return new Promise(function(resolve, reject) {
+				var del = indexedDB.deleteDatabase(db.name);
+				del.onerror = function() { reject(del.error); };
+				del.onsuccess = function() {
+					var req = indexedDB.open(db.name, Math.max(1, db.version));
+					req.onerror = function() { reject(req.error); };
+					req.onupgradeneeded = function() {
+						db.stores.forEach(function(s) {
+							var opts = {autoIncrement: !!s.autoIncrement};
+							if (s.keyPath !== undefined && s.keyPath !== null) {
+								opts.keyPath = s.keyPath;
+							}
+							var os = req.result.createObjectStore(s.name, opts);
+							(s.indexes || []).forEach(function(i) {
+								os.createIndex(i.name, i.keyPath, {unique: !!i.unique, multiEntry: !!i.multiEntry});
+							});
+						});
+					};
+					req.onsuccess = function() {
+						var d = req.result;
+						if (db.stores.length === 0) {
+							d.close();
+							resolve();
+							return;
+						}
+						var tx = d.transaction(db.stores.map(function(s) { return s.name; }), 'readwrite');
+						tx.oncomplete = function() { d.close(); resolve(); };
+						tx.onerror = function() { d.close(); reject(tx.error); };
+						db.stores.forEach(function(s) {
+							var os = tx.objectStore(s.name);
+							(s.records || []).forEach(function(r) {
+								if (os.keyPath === null) {
+									os.put(r.value, r.key);
+								} else {
+									os.put(r.value);
+								}
+							});
+						});
+					};
+				};
+			});
+		})).then(function() { return true; });
+	})(%s, %s)`
+
+	// textMatchJS is a javascript function that returns the innermost
+	// elements of this whose visible text, with whitespace normalized,
+	// matches text using the match mode (exact, contains, or regexp).
+	textMatchJS = `function(mode, text) {
+		var match;
+		switch (mode) {
+		case 'exact':
+			match = function(s) { return s === text; };
+			break;
+		case 'contains':
+			match = function(s) { return s.indexOf(text) !== -1; };
+			break;
+		default:
+			var re = new RegExp(text);
+			match = function(s) { return re.test(s); };
+		}
+		var skip = {HEAD: true, SCRIPT: true, STYLE: true, NOSCRIPT: true, TEMPLATE: true, TITLE: true};
+		var els = Array.prototype.slice.call(this.querySelectorAll('*'));
+		if (this.nodeType === Node.ELEMENT_NODE) {
+			els.unshift(this);
+		}
+		var matched = new Set();
+		els.forEach(function(el) {
+			if (skip[el.tagName]) {
+				return;
+			}
+			var s = el.innerText !== undefined ? el.innerText : el.textContent;
+			if (match(s.replace(/\s+/g, ' ').trim())) {
+				matched.add(el);
+			}
+		});
+		matched.forEach(function(el) {
+			for (var p = el.parentElement; p; p = p.parentElement) {
+				matched.delete(p);
+			}
+		});
+		return Array.from(matched);
+	}`
+
+	// bindingJS is a javascript snippet that wraps the specified runtime
+	// binding with a func that passes its payload to the binding, and returns
+	// a promise resolved (or rejected) with the binding's result when
+	// delivered by deliverBindingJS.
+	bindingJS = `(function(name) {
+		var binding = window[name];
+		if (typeof binding !== 'function' || binding.__chromedp) {
+			return;
+		}
+		var callbacks = new Map();
+		var seq = 0;
+		var removed = null;
+		var f = function(payload) {
+			if (removed !== null) {
+				return Promise.reject(new Error(removed));
+			}
+			var id = ++seq;
+			return new Promise(function(resolve, reject) {
+				callbacks.set(id, {resolve: resolve, reject: reject});
+				binding(JSON.stringify({id: id, payload: payload === undefined ? null : payload}));
+			});
+		};
+		f.__chromedp = function(id, result, err) {
+			var cb = callbacks.get(id);
+			if (!cb) {
+				return;
+			}
+			callbacks.delete(id);
+			if (err !== null) {
+				cb.reject(new Error(err));
+			} else {
+				cb.resolve(result);
+			}
+		};
+		f.__chromedpRemove = function(err) {
+			removed = err;
+			callbacks.forEach(function(cb) {
+				cb.reject(new Error(err));
+			});
+			callbacks.clear();
+		};
+		window[name] = f;
+	})(%s)`
+
+	// deliverBindingJS is a javascript function that delivers the result (or
+	// error) of the named binding's call with the id.
+	deliverBindingJS = `function(name, id, result, err) {
+		window[name].__chromedp(id, result, err);
+	}`
+
+	// unbindJS is a javascript snippet that rejects the pending (and
+	// subsequent) calls of the specified binding wrapped by bindingJS.
+	unbindJS = `(function(name) {
+		var f = window[name];
+		if (typeof f === 'function' && f.__chromedpRemove) {
+			f.__chromedpRemove('binding ' + name + ' removed');
+		}
+	})(%s)`
 )
@@ -3,26 +3,246 @@ package chromedp
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 )
 
-// Navigate navigates the current frame.
-func Navigate(urlstr string) Action {
+// Navigate navigates the current frame, waiting for the lifecycle events
+// specified by the navigation options.
+//
+// When the navigation fails (ie, the host could not be resolved), or the main
+// document's response has a HTTP error status, a *NavigationError is
+// returned.
+func Navigate(urlstr string, opts ...NavigateOption) Action {
+	p := new(navigateParams)
+	for _, o := range opts {
+		o(p)
+	}
+
 	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
 		th, ok := h.(*TargetHandler)
 		if !ok {
 			return ErrInvalidHandler
 		}
 
-		frameID, _, _, err := page.Navigate(urlstr).Do(ctxt, th)
+		if p.timeout != 0 {
+			var cancel func()
+			ctxt, cancel = context.WithTimeout(ctxt, p.timeout)
+			defer cancel()
+		}
+
+		// fail converts a navigation timeout to a navigation error
+		fail := func(err error, event string) error {
+			if p.timeout == 0 || err != context.DeadlineExceeded {
+				return err
+			}
+			text := fmt.Sprintf("timeout after %v", p.timeout)
+			if event != "" {
+				text += " waiting for " + event
+			}
+			return &NavigationError{URL: urlstr, ErrorText: text}
+		}
+
+		frameID, loaderID, errorText, err := page.Navigate(urlstr).Do(ctxt, th)
 		if err != nil {
-			return err
+			return fail(err, "")
+		}
+		if errorText != "" {
+			return &NavigationError{URL: urlstr, ErrorText: errorText}
+		}
+
+		if err = th.SetActive(ctxt, frameID); err != nil {
+			return fail(err, "frame")
+		}
+
+		// same-document navigation
+		if loaderID == "" {
+			return nil
+		}
+
+		for _, event := range p.events {
+			if err = th.waitLifecycle(ctxt, frameID, loaderID, event); err != nil {
+				return fail(err, event)
+			}
+		}
+
+		res, errorText, err := th.waitResponse(ctxt, frameID, loaderID)
+		if err != nil {
+			return fail(err, "response")
+		}
+		if errorText != "" {
+			return &NavigationError{URL: urlstr, ErrorText: errorText}
+		}
+
+		if res != nil && res.Response.Status >= 400 {
+			return &NavigationError{
+				URL:        urlstr,
+				Status:     res.Response.Status,
+				StatusText: res.Response.StatusText,
+			}
+		}
+
+		return nil
+	})
+}
+
+// NavigationError is a navigation error.
+type NavigationError struct {
+	// URL is the navigated URL.
+	URL string
+
+	// ErrorText is the navigation error text (ie, net::ERR_NAME_NOT_RESOLVED),
+	// when the navigation failed.
+	ErrorText string
+
+	// Status and StatusText are the HTTP status of the main document's
+	// response, when the response has a HTTP error status.
+	Status     int64
+	StatusText string
+}
+
+// Error satisfies the error interface.
+func (err *NavigationError) Error() string {
+	if err.ErrorText != "" {
+		return fmt.Sprintf("navigation to %s failed: %s", err.URL, err.ErrorText)
+	}
+	return fmt.Sprintf("navigation to %s failed: %d %s", err.URL, err.Status, err.StatusText)
+}
+
+// navigateParams are the navigation parameters.
+type navigateParams struct {
+	events  []string
+	timeout time.Duration
+}
+
+// NavigateOption is a navigation option.
+type NavigateOption func(*navigateParams)
+
+// NavWaitLoad is a navigation option to wait for the page's load event.
+func NavWaitLoad(p *navigateParams) {
+	p.events = append(p.events, "load")
+}
+
+// NavWaitDOMContentLoaded is a navigation option to wait for the page's
+// DOMContentLoaded event.
+func NavWaitDOMContentLoaded(p *navigateParams) {
+	p.events = append(p.events, "DOMContentLoaded")
+}
+
+// NavWaitNetworkIdle is a navigation option to wait until the page's network
+// is idle (ie, no network connections for at least 500 ms).
+func NavWaitNetworkIdle(p *navigateParams) {
+	p.events = append(p.events, "networkIdle")
+}
+
+// NavWaitFirstMeaningfulPaint is a navigation option to wait for the page's
+// first meaningful paint.
+func NavWaitFirstMeaningfulPaint(p *navigateParams) {
+	p.events = append(p.events, "firstMeaningfulPaint")
+}
+
+// NavTimeout is a navigation option to set the navigation timeout.
+func NavTimeout(d time.Duration) NavigateOption {
+	return func(p *navigateParams) {
+		p.timeout = d
+	}
+}
+
+// frameLifecycle is the set of lifecycle events fired for a frame's loader.
+type frameLifecycle struct {
+	loaderID cdp.LoaderID
+	events   map[string]bool
+}
+
+// lifecycleEvent records a frame lifecycle event.
+func (h *TargetHandler) lifecycleEvent(ev *page.EventLifecycleEvent) {
+	h.Lock()
+	defer h.Unlock()
+
+	l, ok := h.lifecycle[ev.FrameID]
+	if !ok || l.loaderID != ev.LoaderID || ev.Name == "init" {
+		l = &frameLifecycle{loaderID: ev.LoaderID, events: make(map[string]bool)}
+		h.lifecycle[ev.FrameID] = l
+	}
+	l.events[ev.Name] = true
+
+	h.notifyNavigation()
+}
+
+// notifyNavigation signals waiters that a lifecycle event, a document
+// response, or a document request failure has been received.
+//
+// Note: must be called with the lock held.
+func (h *TargetHandler) notifyNavigation() {
+	if h.navUpdate != nil {
+		close(h.navUpdate)
+	}
+	h.navUpdate = make(chan struct{})
+}
+
+// waitNavigation waits until cond returns true, re-checking cond each time a
+// lifecycle event, a document response, or a document request failure is
+// received.
+//
+// Note: cond is called with the lock held.
+func (h *TargetHandler) waitNavigation(ctxt context.Context, cond func() bool) error {
+	for {
+		h.Lock()
+		ok, update := cond(), h.navUpdate
+		h.Unlock()
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-update:
+
+		case <-ctxt.Done():
+			return ctxt.Err()
+		}
+	}
+}
+
+// waitLifecycle waits until the named lifecycle event has been fired for the
+// frame's loader.
+func (h *TargetHandler) waitLifecycle(ctxt context.Context, id cdp.FrameID, loaderID cdp.LoaderID, name string) error {
+	return h.waitNavigation(ctxt, func() bool {
+		l := h.lifecycle[id]
+		return l != nil && l.loaderID == loaderID && l.events[name]
+	})
+}
+
+// waitResponse waits until the frame's document response for the loader has
+// been received, or the loader's document request has failed, returning the
+// response, or the failure's error text.
+//
+// A document committed without a response (ie, about:blank) returns no
+// response.
+func (h *TargetHandler) waitResponse(ctxt context.Context, id cdp.FrameID, loaderID cdp.LoaderID) (*network.EventResponseReceived, string, error) {
+	var res *network.EventResponseReceived
+	var errorText string
+	err := h.waitNavigation(ctxt, func() bool {
+		if r := h.responses[id]; r != nil && r.LoaderID == loaderID {
+			res = r
+			return true
+		}
+
+		if text, ok := h.failures[loaderID]; ok {
+			delete(h.failures, loaderID)
+			errorText = text
+			return true
 		}
 
-		return th.SetActive(ctxt, frameID)
+		// the document response precedes the loader's lifecycle events
+		l := h.lifecycle[id]
+		return l != nil && l.loaderID == loaderID
 	})
+	return res, errorText, err
 }
 
 // NavigationEntries is an action to retrieve the page's navigation history
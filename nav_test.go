@@ -1,10 +1,15 @@
 package chromedp
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 )
 
@@ -47,6 +52,133 @@ func TestNavigate(t *testing.T) {
 	}
 }
 
+func TestNavigateWaitLoad(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow.png":
+			time.Sleep(500 * time.Millisecond)
+			w.WriteHeader(http.StatusNotFound)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprint(w, `<html><body><img src="/slow.png"><script>
+window.addEventListener('load', function() { document.title = 'loaded'; });
+</script></body></html>`)
+		}
+	}))
+	defer s.Close()
+
+	var title string
+	err := c.Run(defaultContext, Tasks{
+		Navigate(s.URL, NavWaitLoad, NavTimeout(5*time.Second)),
+		Title(&title),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "loaded" {
+		t.Errorf("expected title loaded, got: %q", title)
+	}
+
+	err = c.Run(defaultContext, Navigate(s.URL+"/missing", NavWaitLoad))
+	nerr, ok := err.(*NavigationError)
+	if !ok {
+		t.Fatalf("expected *NavigationError, got: %v", err)
+	}
+	if nerr.Status != http.StatusNotFound {
+		t.Errorf("expected status 404, got: %d", nerr.Status)
+	}
+
+	// the main document's response is checked without lifecycle waits
+	err = c.Run(defaultContext, Navigate(s.URL+"/missing"))
+	nerr, ok = err.(*NavigationError)
+	if !ok {
+		t.Fatalf("expected *NavigationError, got: %v", err)
+	}
+	if nerr.Status != http.StatusNotFound {
+		t.Errorf("expected status 404, got: %d", nerr.Status)
+	}
+
+	err = c.Run(defaultContext, Navigate("http://invalid.invalid/"))
+	nerr, ok = err.(*NavigationError)
+	if !ok {
+		t.Fatalf("expected *NavigationError, got: %v", err)
+	}
+	if nerr.ErrorText == "" {
+		t.Errorf("expected error text")
+	}
+}
+
+func TestWaitResponse(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{
+		lifecycle: make(map[cdp.FrameID]*frameLifecycle),
+		responses: make(map[cdp.FrameID]*network.EventResponseReceived),
+		failures:  make(map[cdp.LoaderID]string),
+		inflight:  make(map[network.RequestID]string),
+		navUpdate: make(chan struct{}),
+	}
+
+	// the waiter is signalled by the response event
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		h.trackRequest(&network.EventResponseReceived{
+			FrameID:  "F1",
+			LoaderID: "L1",
+			Type:     network.ResourceTypeDocument,
+			Response: &network.Response{Status: http.StatusNotFound},
+		})
+	}()
+	res, errorText, err := h.waitResponse(defaultContext, "F1", "L1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res == nil || res.Response.Status != http.StatusNotFound || errorText != "" {
+		t.Errorf("expected 404 response, got: %v %q", res, errorText)
+	}
+
+	// and by the document request failure
+	h.trackRequest(&network.EventLoadingFailed{RequestID: "L2", Type: network.ResourceTypeDocument, ErrorText: "net::ERR_ABORTED"})
+	res, errorText, err = h.waitResponse(defaultContext, "F1", "L2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != nil || errorText != "net::ERR_ABORTED" {
+		t.Errorf("expected net::ERR_ABORTED, got: %v %q", res, errorText)
+	}
+
+	// and by the lifecycle of a document committed without a response
+	h.lifecycleEvent(&page.EventLifecycleEvent{FrameID: "F1", LoaderID: "L3", Name: "init"})
+	res, errorText, err = h.waitResponse(defaultContext, "F1", "L3")
+	if err != nil || res != nil || errorText != "" {
+		t.Errorf("expected no response, got: %v %q %v", res, errorText, err)
+	}
+}
+
+func TestNavigationErrorString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err *NavigationError
+		exp string
+	}{
+		{&NavigationError{URL: "http://a/", ErrorText: "net::ERR_NAME_NOT_RESOLVED"}, "navigation to http://a/ failed: net::ERR_NAME_NOT_RESOLVED"},
+		{&NavigationError{URL: "http://a/", Status: 404, StatusText: "Not Found"}, "navigation to http://a/ failed: 404 Not Found"},
+	}
+
+	for i, test := range tests {
+		if s := test.err.Error(); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
 func TestNavigationEntries(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,119 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// trackRequest tracks the in-flight requests and the document responses of
+// the target.
+func (h *TargetHandler) trackRequest(ev interface{}) {
+	h.Lock()
+	defer h.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		h.inflight[e.RequestID] = e.Request.URL
+
+	case *network.EventResponseReceived:
+		if e.Type == network.ResourceTypeDocument {
+			h.responses[e.FrameID] = e
+			h.notifyNavigation()
+		}
+
+	case *network.EventLoadingFinished:
+		delete(h.inflight, e.RequestID)
+
+	case *network.EventLoadingFailed:
+		delete(h.inflight, e.RequestID)
+
+		// the id of a document request is its loader's id
+		if e.Type == network.ResourceTypeDocument && h.failures != nil {
+			h.failures[cdp.LoaderID(e.RequestID)] = e.ErrorText
+			h.notifyNavigation()
+		}
+	}
+}
+
+// pendingRequests returns the sorted URLs of the in-flight requests.
+func (h *TargetHandler) pendingRequests() []string {
+	h.RLock()
+	defer h.RUnlock()
+
+	urls := make([]string, 0, len(h.inflight))
+	for _, urlstr := range h.inflight {
+		urls = append(urls, urlstr)
+	}
+	sort.Strings(urls)
+
+	return urls
+}
+
+// NetworkIdleError is the error returned by WaitNetworkIdle when the context
+// is done before the network is idle.
+type NetworkIdleError struct {
+	// Pending are the URLs of the requests in-flight when the context was
+	// done.
+	Pending []string
+
+	// Err is the context error.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *NetworkIdleError) Error() string {
+	return fmt.Sprintf("waiting for network idle: %v (%d pending: %s)", err.Err, len(err.Pending), strings.Join(err.Pending, ", "))
+}
+
+// WaitNetworkIdle is an action that waits until there are no more than
+// maxInflight in-flight network requests for the current target, for a
+// continuous quiet period.
+//
+// When the context is done, or the handler's wait timeout expires, before the
+// network is idle, a *NetworkIdleError reporting the URLs of the pending
+// requests is returned.
+func WaitNetworkIdle(maxInflight int, quiet time.Duration) Action {
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		timeout := th.waitTimeoutChan()
+
+		var since time.Time
+		for {
+			th.RLock()
+			n := len(th.inflight)
+			th.RUnlock()
+
+			switch {
+			case n > maxInflight:
+				since = time.Time{}
+			case since.IsZero():
+				since = time.Now()
+			}
+
+			if !since.IsZero() && time.Since(since) >= quiet {
+				return nil
+			}
+
+			select {
+			case <-time.After(DefaultCheckDuration):
+
+			case <-ctxt.Done():
+				return &NetworkIdleError{Pending: th.pendingRequests(), Err: ctxt.Err()}
+
+			case <-timeout:
+				return &NetworkIdleError{Pending: th.pendingRequests(), Err: errors.New("timeout")}
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestWaitNetworkIdlePending(t *testing.T) {
+	t.Parallel()
+
+	h := &TargetHandler{
+		inflight:  make(map[network.RequestID]string),
+		responses: make(map[cdp.FrameID]*network.EventResponseReceived),
+	}
+	for _, ev := range []interface{}{
+		&network.EventRequestWillBeSent{RequestID: "1", Request: &network.Request{URL: "http://localhost/b"}},
+		&network.EventRequestWillBeSent{RequestID: "2", Request: &network.Request{URL: "http://localhost/a"}},
+		&network.EventRequestWillBeSent{RequestID: "3", Request: &network.Request{URL: "http://localhost/c"}},
+		&network.EventLoadingFinished{RequestID: "3"},
+	} {
+		h.trackRequest(ev)
+	}
+
+	ctxt, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := WaitNetworkIdle(1, 0).Do(ctxt, h)
+	nerr, ok := err.(*NetworkIdleError)
+	if !ok {
+		t.Fatalf("expected *NetworkIdleError, got: %v", err)
+	}
+	if exp := []string{"http://localhost/a", "http://localhost/b"}; !reflect.DeepEqual(nerr.Pending, exp) {
+		t.Errorf("expected %v, got: %v", exp, nerr.Pending)
+	}
+
+	// the handler's wait timeout bounds the wait
+	h.SetTimeouts(0, 100*time.Millisecond)
+	err = WaitNetworkIdle(1, 0).Do(context.Background(), h)
+	if _, ok := err.(*NetworkIdleError); !ok {
+		t.Errorf("expected *NetworkIdleError, got: %v", err)
+	}
+	h.SetTimeouts(0, 0)
+
+	h.trackRequest(&network.EventLoadingFailed{RequestID: "1"})
+	if err = WaitNetworkIdle(1, 100*time.Millisecond).Do(context.Background(), h); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestWaitNetworkIdle(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "")
+	defer c.Release()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(500 * time.Millisecond)
+			fmt.Fprint(w, "done")
+			return
+		}
+		fmt.Fprint(w, `<html><body><button id="load" onclick="fetch('/slow').then(r => r.text()).then(t => document.getElementById('result').textContent = t)">load</button><div id="result"></div></body></html>`)
+	}))
+	defer s.Close()
+
+	var result string
+	err := c.Run(defaultContext, Tasks{
+		Navigate(s.URL, NavWaitLoad),
+		Click("#load", ByID),
+		WaitNetworkIdle(0, 200*time.Millisecond),
+		Text("#result", &result, ByID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "done" {
+		t.Errorf("expected done, got: %q", result)
+	}
+}
@@ -68,7 +68,7 @@ func Blur(sel interface{}, opts ...QueryOption) Action {
 		}
 
 		var res bool
-		err := EvaluateAsDevTools(fmt.Sprintf(blurJS, nodes[0].FullXPath()), &res).Do(ctxt, h)
+		err := callNodeFunc(ctxt, h, nodes[0], blurJS, &res)
 		if err != nil {
 			return err
 		}
@@ -108,7 +108,7 @@ func Text(sel interface{}, text *string, opts ...QueryOption) Action {
 			return fmt.Errorf("selector `%s` did not return any nodes", sel)
 		}
 
-		return EvaluateAsDevTools(fmt.Sprintf(textJS, nodes[0].FullXPath()), text).Do(ctxt, h)
+		return callNodeFunc(ctxt, h, nodes[0], textJS, text)
 	}, opts...)
 }
 
@@ -327,7 +327,7 @@ func JavascriptAttribute(sel interface{}, name string, res interface{}, opts ...
 			return fmt.Errorf("selector `%s` did not return any nodes", sel)
 		}
 
-		return EvaluateAsDevTools(fmt.Sprintf(attributeJS, nodes[0].FullXPath(), name), res).Do(ctxt, h)
+		return callNodeFunc(ctxt, h, nodes[0], attributeJS, res, name)
 	}, opts...)
 }
 
@@ -340,7 +340,7 @@ func SetJavascriptAttribute(sel interface{}, name, value string, opts ...QueryOp
 		}
 
 		var res string
-		err := EvaluateAsDevTools(fmt.Sprintf(setAttributeJS, nodes[0].FullXPath(), name, value), &res).Do(ctxt, h)
+		err := callNodeFunc(ctxt, h, nodes[0], setAttributeJS, &res, name, value)
 		if err != nil {
 			return err
 		}
@@ -505,7 +505,7 @@ func Submit(sel interface{}, opts ...QueryOption) Action {
 		}
 
 		var res bool
-		err := EvaluateAsDevTools(fmt.Sprintf(submitJS, nodes[0].FullXPath()), &res).Do(ctxt, h)
+		err := callNodeFunc(ctxt, h, nodes[0], submitJS, &res)
 		if err != nil {
 			return err
 		}
@@ -527,7 +527,7 @@ func Reset(sel interface{}, opts ...QueryOption) Action {
 		}
 
 		var res bool
-		err := EvaluateAsDevTools(fmt.Sprintf(resetJS, nodes[0].FullXPath()), &res).Do(ctxt, h)
+		err := callNodeFunc(ctxt, h, nodes[0], resetJS, &res)
 		if err != nil {
 			return err
 		}
@@ -597,7 +597,7 @@ func ScrollIntoView(sel interface{}, opts ...QueryOption) Action {
 		}
 
 		var pos []int
-		err := EvaluateAsDevTools(fmt.Sprintf(scrollIntoViewJS, nodes[0].FullXPath()), &pos).Do(ctxt, h)
+		err := callNodeFunc(ctxt, h, nodes[0], scrollIntoViewJS, &pos)
 		if err != nil {
 			return err
 		}
@@ -355,6 +355,26 @@ func TestSetValue(t *testing.T) {
 	}
 }
 
+func TestSetValueQuoted(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "shadow.html")
+	defer c.Release()
+
+	exp := `it's "quoted" \ '); alert('injected`
+	var value string
+	err := c.Run(defaultContext, Tasks{
+		SetValue("#app >>> #name", exp, ByQuery),
+		Value("#app >>> #name", &value, ByQuery),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if value != exp {
+		t.Errorf("expected %q, got: %q", exp, value)
+	}
+}
+
 func TestAttributes(t *testing.T) {
 	t.Parallel()
 
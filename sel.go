@@ -2,33 +2,36 @@ package chromedp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/accessibility"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 )
 
-/*
-
-TODO: selector 'by' type, as below:
-classname
-linktext
-name
-partiallinktext
-tagname
-
-*/
-
 // Selector holds information pertaining to an element query select action.
 type Selector struct {
 	sel   interface{}
 	exp   int
+	root  func(context.Context, *TargetHandler) (*TargetHandler, *cdp.Node, error)
 	by    func(context.Context, *TargetHandler, *cdp.Node) ([]cdp.NodeID, error)
 	wait  func(context.Context, *TargetHandler, *cdp.Node, ...cdp.NodeID) ([]*cdp.Node, error)
 	after func(context.Context, *TargetHandler, ...*cdp.Node) error
+
+	// byName is the name of the query option used to select elements (ie,
+	// ByQuery, BySearch), reported in query errors.
+	byName string
+
+	// timeout is the query timeout, overriding the handler's default query
+	// timeout when non-zero.
+	timeout time.Duration
 }
 
 // Query is an action to query for document nodes match the specified sel and
@@ -62,15 +65,134 @@ func (s *Selector) Do(ctxt context.Context, h cdp.Executor) error {
 		return ErrInvalidHandler
 	}
 
-	// TODO: fix this
-	ctxt, cancel := context.WithTimeout(ctxt, 100*time.Second)
+	timeout := s.timeout
+	if timeout == 0 {
+		th.RLock()
+		timeout = th.queryTimeout
+		th.RUnlock()
+	}
+	if timeout != 0 {
+		var cancel func()
+		ctxt, cancel = context.WithTimeout(ctxt, timeout)
+		defer cancel()
+	}
+
+	// wait for the run to finish, so that its last poll's state has been
+	// recorded before building the query error
+	st := new(queryState)
+	err := <-s.run(ctxt, th, st)
+
+	if err == context.DeadlineExceeded {
+		return s.queryError(th, st, timeout)
+	}
+
+	return err
+}
+
+// queryState is the state of a selector's last poll.
+type queryState struct {
+	sync.Mutex
+	matched int
+	err     error
+}
+
+// set records the number of nodes matched and the error of the last poll,
+// unless the poll was cut off by ctxt being done.
+func (st *queryState) set(ctxt context.Context, matched int, err error) {
+	if ctxt.Err() != nil {
+		return
+	}
+
+	st.Lock()
+	defer st.Unlock()
+
+	st.matched, st.err = matched, err
+}
+
+// QueryError is the error returned when a query times out before its selector
+// matched and its wait conditions were met.
+type QueryError struct {
+	// Selector is the query's selector.
+	Selector string
+
+	// By is the name of the query option used to select elements (ie,
+	// BySearch).
+	By string
+
+	// Expected is the number of nodes the query expected to match, and
+	// Matched is the number of nodes matched by the last poll.
+	Expected int
+	Matched  int
+
+	// Err is the error of the last poll, such as the failed wait condition
+	// (ie, ErrNotVisible, ErrDisabled).
+	Err error
+
+	// Timeout is the query's timeout.
+	Timeout time.Duration
+
+	// Screenshot and HTML are a screenshot and the outer HTML of the document
+	// captured when the query timed out. Only captured when query
+	// diagnostics are enabled (see WithQueryDiagnostics).
+	Screenshot []byte
+	HTML       string
+}
+
+// Error satisfies the error interface.
+func (err *QueryError) Error() string {
+	msg := fmt.Sprintf("timeout waiting for selector `%s` (%s): %d of %d nodes matched", err.Selector, err.By, err.Matched, err.Expected)
+	if err.Timeout != 0 {
+		msg += fmt.Sprintf(" after %v", err.Timeout)
+	}
+	if err.Err != nil {
+		msg += ": " + err.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error of the query's last poll.
+func (err *QueryError) Unwrap() error {
+	return err.Err
+}
+
+// queryDiagnosticsTimeout is the time allowed to capture query diagnostics.
+const queryDiagnosticsTimeout = 5 * time.Second
+
+// queryError builds the query error for a timed out query, capturing a
+// screenshot and the document's outer HTML when query diagnostics are
+// enabled.
+func (s *Selector) queryError(th *TargetHandler, st *queryState, timeout time.Duration) error {
+	st.Lock()
+	err := &QueryError{
+		Selector: fmt.Sprintf("%v", s.sel),
+		By:       s.byName,
+		Expected: s.exp,
+		Matched:  st.matched,
+		Err:      st.err,
+		Timeout:  timeout,
+	}
+	st.Unlock()
+
+	th.RLock()
+	diagnostics := th.queryDiagnostics
+	th.RUnlock()
+	if !diagnostics {
+		return err
+	}
+
+	// the query's context has expired, so capture with a fresh context
+	ctxt, cancel := context.WithTimeout(context.Background(), queryDiagnosticsTimeout)
 	defer cancel()
 
-	var err error
-	select {
-	case err = <-s.run(ctxt, th):
-	case <-ctxt.Done():
-		err = ctxt.Err()
+	if buf, e := page.CaptureScreenshot().Do(ctxt, th); e == nil {
+		err.Screenshot = buf
+	}
+	// use the root the handler already holds, as requesting the document
+	// again would invalidate every node id held by the handler
+	if root, e := th.GetRoot(ctxt); e == nil {
+		if html, e := dom.GetOuterHTML().WithNodeID(root.NodeID).Do(ctxt, th); e == nil {
+			err.HTML = html
+		}
 	}
 
 	return err
@@ -79,20 +201,21 @@ func (s *Selector) Do(ctxt context.Context, h cdp.Executor) error {
 // run runs the selector action, starting over if the original returned nodes
 // are invalidated prior to finishing the selector's by, wait, check, and after
 // funcs.
-func (s *Selector) run(ctxt context.Context, h *TargetHandler) chan error {
+func (s *Selector) run(ctxt context.Context, th *TargetHandler, st *queryState) chan error {
 	ch := make(chan error, 1)
 
 	go func() {
 		defer close(ch)
 
 		for {
-			root, err := h.GetRoot(ctxt)
+			h, root, err := s.resolveRoot(ctxt, th)
 			if err != nil {
+				st.set(ctxt, 0, err)
 				select {
 				case <-ctxt.Done():
 					ch <- ctxt.Err()
 					return
-				default:
+				case <-time.After(DefaultCheckDuration):
 					continue
 				}
 			}
@@ -100,8 +223,10 @@ func (s *Selector) run(ctxt context.Context, h *TargetHandler) chan error {
 			select {
 			default:
 				ids, err := s.by(ctxt, h, root)
+				st.set(ctxt, len(ids), err)
 				if err == nil && len(ids) >= s.exp {
 					nodes, err := s.wait(ctxt, h, root, ids...)
+					st.set(ctxt, len(ids), err)
 					if err == nil {
 						if s.after == nil {
 							return
@@ -130,6 +255,21 @@ func (s *Selector) run(ctxt context.Context, h *TargetHandler) chan error {
 	return ch
 }
 
+// resolveRoot returns the handler and root node the selector's query is run
+// against.
+func (s *Selector) resolveRoot(ctxt context.Context, h *TargetHandler) (*TargetHandler, *cdp.Node, error) {
+	if s.root != nil {
+		return s.root(ctxt, h)
+	}
+
+	root, err := h.GetRoot(ctxt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return h, root, nil
+}
+
 // selAsString forces sel into a string.
 func (s *Selector) selAsString() string {
 	if sel, ok := s.sel.(string); ok {
@@ -159,17 +299,150 @@ func QueryAfter(sel interface{}, f func(context.Context, *TargetHandler, ...*cdp
 // QueryOption is a element query selector option.
 type QueryOption func(*Selector)
 
+// InFrame is a query option to run the query inside the document of the first
+// frame owner element (ie, iframe) matching sel and the supplied query
+// options.
+//
+// Out-of-process (ie, cross-origin) iframes are queried using the handler of
+// the iframe's target, and input events are dispatched relative to the
+// iframe's position.
+func InFrame(sel interface{}, opts ...QueryOption) QueryOption {
+	return func(s *Selector) {
+		s.root = func(ctxt context.Context, h *TargetHandler) (*TargetHandler, *cdp.Node, error) {
+			var nodes []*cdp.Node
+			if err := Nodes(sel, &nodes, opts...).Do(ctxt, h); err != nil {
+				return nil, nil, err
+			}
+
+			n := nodes[0]
+			n.RLock()
+			frameID, doc := n.FrameID, n.ContentDocument
+			n.RUnlock()
+
+			if frameID == cdp.EmptyFrameID {
+				return nil, nil, fmt.Errorf("selector `%s` did not match a frame owner element", sel)
+			}
+
+			// out-of-process iframe
+			if fh := h.FrameHandler(frameID); fh != nil {
+				root, err := fh.GetRoot(ctxt)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				return fh, root, nil
+			}
+
+			// same-origin iframe, whose document is part of h's node tree
+			if doc == nil {
+				if err := dom.RequestChildNodes(n.NodeID).WithPierce(true).Do(ctxt, h); err != nil {
+					return nil, nil, err
+				}
+				return nil, nil, fmt.Errorf("frame %s document not loaded", frameID)
+			}
+
+			return h, doc, nil
+		}
+	}
+}
+
+// Within is a query option to run the query relative to the first element
+// matching sel and the supplied query options (ie, to select the "Edit"
+// button of a specific table row).
+//
+// The element is selected again when the document is updated (ie, after a
+// navigation or reload), before the query is retried.
+func Within(sel interface{}, opts ...QueryOption) QueryOption {
+	return func(s *Selector) {
+		s.root = func(ctxt context.Context, h *TargetHandler) (*TargetHandler, *cdp.Node, error) {
+			var nodes []*cdp.Node
+			if err := Nodes(sel, &nodes, opts...).Do(ctxt, h); err != nil {
+				return nil, nil, err
+			}
+
+			return h, nodes[0], nil
+		}
+	}
+}
+
+// FromNode is a query option to run the query relative to the node n, as
+// previously retrieved by the Nodes action.
+//
+// Unlike Within, n cannot be selected again when the document is updated, so
+// the query times out with an invalidated node error once n's document has
+// been invalidated.
+func FromNode(n *cdp.Node) QueryOption {
+	return func(s *Selector) {
+		s.root = func(ctxt context.Context, h *TargetHandler) (*TargetHandler, *cdp.Node, error) {
+			n.RLock()
+			invalidated := n.Invalidated
+			n.RUnlock()
+
+			select {
+			case <-invalidated:
+				return nil, nil, fmt.Errorf("node %d was invalidated", n.NodeID)
+			default:
+			}
+
+			return h, n, nil
+		}
+	}
+}
+
+// isDescendant determines if the node with the specified id is root or a
+// descendant of root in h's node tree.
+func isDescendant(h *TargetHandler, root *cdp.Node, id cdp.NodeID) bool {
+	h.RLock()
+	cur := h.cur
+	h.RUnlock()
+	if cur == nil {
+		return false
+	}
+
+	cur.RLock()
+	n, ok := cur.Nodes[id]
+	cur.RUnlock()
+	if !ok {
+		return false
+	}
+
+	for n != nil {
+		if n == root {
+			return true
+		}
+
+		n.RLock()
+		p := n.Parent
+		n.RUnlock()
+		n = p
+	}
+
+	return false
+}
+
 // ByFunc is a query option to set the func used to select elements.
 func ByFunc(f func(context.Context, *TargetHandler, *cdp.Node) ([]cdp.NodeID, error)) QueryOption {
 	return func(s *Selector) {
 		s.by = f
+		s.byName = "ByFunc"
 	}
 }
 
 // ByQuery is a query option to select a single element using
 // DOM.querySelector.
+//
+// The selector can pierce shadow roots using the >>> combinator (see
+// ShadowSeparator).
 func ByQuery(s *Selector) {
 	ByFunc(func(ctxt context.Context, h *TargetHandler, n *cdp.Node) ([]cdp.NodeID, error) {
+		if parts := splitShadow(s.selAsString()); len(parts) > 1 {
+			ids, err := queryShadow(ctxt, h, n, parts)
+			if err != nil || len(ids) == 0 {
+				return ids, err
+			}
+			return ids[:1], nil
+		}
+
 		nodeID, err := dom.QuerySelector(n.NodeID, s.selAsString()).Do(ctxt, h)
 		if err != nil {
 			return nil, err
@@ -181,19 +454,382 @@ func ByQuery(s *Selector) {
 
 		return []cdp.NodeID{nodeID}, nil
 	})(s)
+	s.byName = "ByQuery"
 }
 
 // ByQueryAll is a query option to select elements by DOM.querySelectorAll.
+//
+// The selector can pierce shadow roots using the >>> combinator (see
+// ShadowSeparator).
 func ByQueryAll(s *Selector) {
 	ByFunc(func(ctxt context.Context, h *TargetHandler, n *cdp.Node) ([]cdp.NodeID, error) {
+		if parts := splitShadow(s.selAsString()); len(parts) > 1 {
+			return queryShadow(ctxt, h, n, parts)
+		}
+
 		return dom.QuerySelectorAll(n.NodeID, s.selAsString()).Do(ctxt, h)
 	})(s)
+	s.byName = "ByQueryAll"
+}
+
+// ShadowSeparator is the shadow-piercing combinator for ByQuery and
+// ByQueryAll selectors. The selector following the combinator selects elements
+// within the (open or closed) shadow roots of the elements selected by the
+// selector preceding it. For example, "my-app >>> form button" selects the
+// buttons of the forms in the shadow root of <my-app> elements, and
+// "my-app >>> my-dialog >>> button" selects buttons nested in two shadow
+// roots.
+const ShadowSeparator = ">>>"
+
+// splitShadow splits sel at the shadow-piercing combinators outside of
+// strings, attribute selectors and pseudo-class arguments (ie,
+// `[title=">>>"]`).
+func splitShadow(sel string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(sel); i++ {
+		switch c := sel[i]; {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && strings.HasPrefix(sel[i:], ShadowSeparator):
+			parts = append(parts, sel[start:i])
+			i += len(ShadowSeparator) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, sel[start:])
+}
+
+// queryShadow selects the elements matching the parts of a shadow-piercing
+// selector (see splitShadow) within n.
+func queryShadow(ctxt context.Context, h *TargetHandler, n *cdp.Node, parts []string) ([]cdp.NodeID, error) {
+	roots := []cdp.NodeID{n.NodeID}
+	for i, part := range parts {
+		var ids []cdp.NodeID
+		for _, root := range roots {
+			res, err := dom.QuerySelectorAll(root, strings.TrimSpace(part)).Do(ctxt, h)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, res...)
+		}
+
+		if i == len(parts)-1 {
+			return ids, nil
+		}
+
+		var err error
+		roots, err = h.shadowRoots(ctxt, ids...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// shadowRoots returns the node ids of the shadow roots (excluding user agent
+// shadow roots) attached to the nodes, as tracked by the handler's node tree.
+func (h *TargetHandler) shadowRoots(ctxt context.Context, ids ...cdp.NodeID) ([]cdp.NodeID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	f, err := h.WaitFrame(ctxt, cdp.EmptyFrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []cdp.NodeID
+	for _, id := range ids {
+		n, err := h.WaitNode(ctxt, f, id)
+		if err != nil {
+			return nil, err
+		}
+
+		n.RLock()
+		for _, r := range n.ShadowRoots {
+			if r.ShadowRootType != cdp.ShadowRootTypeUserAgent {
+				roots = append(roots, r.NodeID)
+			}
+		}
+		n.RUnlock()
+	}
+
+	return roots, nil
 }
 
 // ByID is a query option to select a single element by their CSS #id.
 func ByID(s *Selector) {
 	s.sel = "#" + strings.TrimPrefix(s.selAsString(), "#")
 	ByQuery(s)
+	s.byName = "ByID"
+}
+
+// ByClassName is a query option to select elements by their CSS class name.
+func ByClassName(s *Selector) {
+	s.sel = "." + cssIdent(strings.TrimPrefix(s.selAsString(), "."))
+	ByQueryAll(s)
+	s.byName = "ByClassName"
+}
+
+// ByName is a query option to select elements by their name attribute.
+func ByName(s *Selector) {
+	s.sel = "[name=" + cssString(s.selAsString()) + "]"
+	ByQueryAll(s)
+	s.byName = "ByName"
+}
+
+// ByTagName is a query option to select elements by their tag name.
+func ByTagName(s *Selector) {
+	s.sel = cssIdent(s.selAsString())
+	ByQueryAll(s)
+	s.byName = "ByTagName"
+}
+
+// ByLinkText is a query option to select links (ie, <a> elements) whose text,
+// with whitespace normalized, exactly matches the selector.
+func ByLinkText(s *Selector) {
+	s.sel = "//a[normalize-space(.)=" + xpathString(strings.TrimSpace(s.selAsString())) + "]"
+	BySearch(s)
+	s.byName = "ByLinkText"
+}
+
+// ByPartialLinkText is a query option to select links (ie, <a> elements)
+// whose text, with whitespace normalized, contains the selector.
+func ByPartialLinkText(s *Selector) {
+	s.sel = "//a[contains(normalize-space(.), " + xpathString(s.selAsString()) + ")]"
+	BySearch(s)
+	s.byName = "ByPartialLinkText"
+}
+
+// ByText is a query option to select the innermost elements whose visible
+// text (ie, innerText), with whitespace normalized, exactly matches the
+// selector.
+func ByText(s *Selector) {
+	byText(s, "exact")
+	s.byName = "ByText"
+}
+
+// ByTextContains is a query option to select the innermost elements whose
+// visible text (ie, innerText), with whitespace normalized, contains the
+// selector.
+func ByTextContains(s *Selector) {
+	byText(s, "contains")
+	s.byName = "ByTextContains"
+}
+
+// ByTextRegexp is a query option to select the innermost elements whose
+// visible text (ie, innerText), with whitespace normalized, matches the
+// selector as a javascript regular expression (ie, "^Sign (in|up)$").
+func ByTextRegexp(s *Selector) {
+	byText(s, "regexp")
+	s.byName = "ByTextRegexp"
+}
+
+// byText sets the selector's by func to select elements by their visible
+// text using the textMatchJS match mode.
+func byText(s *Selector, mode string) {
+	ByFunc(func(ctxt context.Context, h *TargetHandler, n *cdp.Node) ([]cdp.NodeID, error) {
+		return callNodesFunc(ctxt, h, n, textMatchJS, mode, s.selAsString())
+	})(s)
+}
+
+// ByRole is a query option to select elements by their ARIA role (ie,
+// "button", "link", "textbox"), and accessible name, as computed by the
+// browser's accessibility tree. An empty name matches elements with any
+// accessible name. Elements ignored by the accessibility tree (ie, hidden
+// elements) are not selected.
+//
+// The query's selector is ignored.
+func ByRole(role, name string) QueryOption {
+	return func(s *Selector) {
+		s.sel = fmt.Sprintf("role=%s name=%q", role, name)
+		ByFunc(func(ctxt context.Context, h *TargetHandler, n *cdp.Node) ([]cdp.NodeID, error) {
+			ax, err := accessibility.GetFullAXTree().Do(ctxt, h)
+			if err != nil {
+				return nil, err
+			}
+
+			matched := make(map[cdp.BackendNodeID]bool)
+			for _, x := range ax {
+				if !x.Ignored && x.BackendDOMNodeID != 0 &&
+					axValue(x.Role) == role &&
+					(name == "" || axValue(x.Name) == name) {
+					matched[x.BackendDOMNodeID] = true
+				}
+			}
+			if len(matched) == 0 {
+				return []cdp.NodeID{}, nil
+			}
+
+			// select the matched elements within n, in document order
+			ids, err := dom.QuerySelectorAll(n.NodeID, "*").Do(ctxt, h)
+			if err != nil {
+				return nil, err
+			}
+
+			f, err := h.WaitFrame(ctxt, cdp.EmptyFrameID)
+			if err != nil {
+				return nil, err
+			}
+
+			var nodes []cdp.NodeID
+			for _, id := range ids {
+				x, err := h.WaitNode(ctxt, f, id)
+				if err != nil {
+					return nil, err
+				}
+
+				x.RLock()
+				backendID := x.BackendNodeID
+				x.RUnlock()
+
+				if matched[backendID] {
+					nodes = append(nodes, id)
+				}
+			}
+
+			return nodes, nil
+		})(s)
+		s.byName = "ByRole"
+	}
+}
+
+// axValue returns the string value of an accessibility value.
+func axValue(v *accessibility.Value) string {
+	if v == nil {
+		return ""
+	}
+
+	var s string
+	_ = json.Unmarshal(v.Value, &s)
+	return s
+}
+
+// callNodesFunc calls the javascript function fn with the node n as this,
+// and the JSON-encoded args as arguments, returning the node ids of the
+// elements in the array returned by fn.
+func callNodesFunc(ctxt context.Context, h *TargetHandler, n *cdp.Node, fn string, args ...interface{}) ([]cdp.NodeID, error) {
+	group := fmt.Sprintf("chromedp-%d", atomic.AddUint64(&objectGroupID, 1))
+	defer runtime.ReleaseObjectGroup(group).Do(ctxt, h)
+
+	obj, err := dom.ResolveNode().WithNodeID(n.NodeID).WithObjectGroup(group).Do(ctxt, h)
+	if err != nil {
+		return nil, err
+	}
+
+	callArgs, err := callArguments(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, exp, err := runtime.CallFunctionOn(fn).
+		WithObjectID(obj.ObjectID).
+		WithArguments(callArgs).
+		Do(ctxt, h)
+	switch {
+	case err != nil:
+		return nil, err
+	case exp != nil:
+		return nil, exp
+	case res.ObjectID == "":
+		return nil, fmt.Errorf("expected array of elements, got: %s", res.Type)
+	}
+
+	props, _, exp, err := runtime.GetProperties(res.ObjectID).WithOwnProperties(true).Do(ctxt, h)
+	switch {
+	case err != nil:
+		return nil, err
+	case exp != nil:
+		return nil, exp
+	}
+
+	ids := []cdp.NodeID{}
+	for _, p := range props {
+		if p.Value == nil || p.Value.Subtype != runtime.SubtypeNode {
+			continue
+		}
+
+		id, err := dom.RequestNode(p.Value.ObjectID).Do(ctxt, h)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// objectGroupID is the last used remote object group id.
+var objectGroupID uint64
+
+// cssIdent escapes s for use as a CSS identifier.
+func cssIdent(s string) string {
+	var buf strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r >= 0x80,
+			r == '-' && (i != 0 || len(s) > 1),
+			r >= '0' && r <= '9' && i != 0 && (i != 1 || s[0] != '-'):
+			buf.WriteRune(r)
+		case r >= '0' && r <= '9', r < 0x20, r == 0x7f:
+			fmt.Fprintf(&buf, "\\%x ", r)
+		default:
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// cssString quotes s as a CSS string.
+func cssString(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"', r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20, r == 0x7f:
+			fmt.Fprintf(&buf, "\\%x ", r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// xpathString quotes s as an XPath string literal, using concat() when s
+// contains both single and double quotes.
+func xpathString(s string) string {
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	}
+
+	parts := strings.Split(s, `"`)
+	for i, p := range parts {
+		parts[i] = `"` + p + `"`
+	}
+	return "concat(" + strings.Join(parts, `, '"', `) + ")"
 }
 
 // BySearch is a query option via DOM.performSearch (works with both CSS and
@@ -214,8 +850,24 @@ func BySearch(s *Selector) {
 			return nil, err
 		}
 
+		// searches span all documents, so only keep the results
+		// within n when n is not the top level document
+		n.RLock()
+		scoped := n.Parent != nil
+		n.RUnlock()
+		if scoped {
+			var ids []cdp.NodeID
+			for _, id := range nodes {
+				if isDescendant(h, n, id) {
+					ids = append(ids, id)
+				}
+			}
+			nodes = ids
+		}
+
 		return nodes, nil
 	})(s)
+	s.byName = "BySearch"
 }
 
 // ByNodeID is a query option to select elements by their NodeIDs.
@@ -235,6 +887,7 @@ func ByNodeID(s *Selector) {
 
 		return ids, nil
 	})(s)
+	s.byName = "ByNodeID"
 }
 
 // waitReady waits for the specified nodes to be ready.
@@ -385,6 +1038,15 @@ func AtLeast(n int) QueryOption {
 	}
 }
 
+// WithTimeout is a query option to set the time to wait for the selector to
+// match and for the query's conditions to be met, overriding the handler's
+// default query timeout (see WithQueryTimeout).
+func WithTimeout(d time.Duration) QueryOption {
+	return func(s *Selector) {
+		s.timeout = d
+	}
+}
+
 // After is a query option to set a func that will be executed after the wait
 // has succeeded.
 func After(f func(context.Context, *TargetHandler, ...*cdp.Node) error) QueryOption {
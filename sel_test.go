@@ -1,9 +1,20 @@
 package chromedp
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
+
+	"github.com/chromedp/chromedp/runner"
 )
 
 func TestWaitReady(t *testing.T) {
@@ -222,3 +233,390 @@ func TestAtLeast(t *testing.T) {
 		t.Errorf("expected to have at least 3 nodes: got %d", len(nodes))
 	}
 }
+
+func TestWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "js.html")
+	defer c.Release()
+
+	start := time.Now()
+	err := c.Run(defaultContext, WaitVisible("#missing", ByID, WithTimeout(100*time.Millisecond)))
+	if err == nil || !strings.Contains(err.Error(), "#missing") {
+		t.Fatalf("expected timeout error for #missing, got: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected query to time out after 100ms, took: %v", d)
+	}
+}
+
+func TestQueryErrorString(t *testing.T) {
+	t.Parallel()
+
+	err := &QueryError{
+		Selector: "#box1",
+		By:       "ByID",
+		Expected: 1,
+		Matched:  1,
+		Err:      ErrNotVisible,
+		Timeout:  time.Second,
+	}
+	exp := "timeout waiting for selector `#box1` (ByID): 1 of 1 nodes matched after 1s: not visible"
+	if err.Error() != exp {
+		t.Errorf("expected %q, got: %q", exp, err.Error())
+	}
+	if !errors.Is(err, ErrNotVisible) {
+		t.Errorf("expected error to wrap %v", ErrNotVisible)
+	}
+}
+
+func TestQueryStateSet(t *testing.T) {
+	t.Parallel()
+
+	st := new(queryState)
+	st.set(context.Background(), 1, ErrNotVisible)
+
+	// a poll cut off by the deadline does not replace the last poll's state
+	ctxt, cancel := context.WithCancel(context.Background())
+	cancel()
+	st.set(ctxt, 0, context.Canceled)
+	if st.matched != 1 || st.err != ErrNotVisible {
+		t.Errorf("expected 1 match that is not visible, got: %d %v", st.matched, st.err)
+	}
+}
+
+func TestQueryErrorDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "visible.html")
+	defer c.Release()
+
+	th := c.CDP().GetHandlerByIndex(0).(*TargetHandler)
+	th.SetQueryDiagnostics(true)
+
+	err := c.Run(defaultContext, WaitVisible("#box1", ByID, WithTimeout(500*time.Millisecond)))
+	qerr, ok := err.(*QueryError)
+	if !ok {
+		t.Fatalf("expected *QueryError, got: %v", err)
+	}
+	if qerr.By != "ByID" || qerr.Matched != 1 || qerr.Err != ErrNotVisible {
+		t.Errorf("expected ByID with 1 match that is not visible, got: %v", qerr)
+	}
+	if len(qerr.Screenshot) == 0 {
+		t.Error("expected screenshot")
+	}
+	if !strings.Contains(qerr.HTML, `id="box1"`) {
+		t.Errorf("expected document HTML, got: %q", qerr.HTML)
+	}
+}
+
+func TestSelectorEscaping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		f      func(string) string
+		s, exp string
+	}{
+		{cssIdent, "item", "item"},
+		{cssIdent, "2col", `\32 col`},
+		{cssIdent, "-1", `-\31 `},
+		{cssIdent, "-", `\-`},
+		{cssIdent, "a.b", `a\.b`},
+		{cssString, "user[name]", `"user[name]"`},
+		{cssString, `a"b\c`, `"a\"b\\c"`},
+		{xpathString, "Home", `"Home"`},
+		{xpathString, `About "us"`, `'About "us"'`},
+		{xpathString, `It's "contact"`, `concat("It's ", '"', "contact", '"', "")`},
+	}
+
+	for i, test := range tests {
+		if got := test.f(test.s); got != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, got)
+		}
+	}
+}
+
+func TestSplitShadow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sel string
+		exp []string
+	}{
+		{"form button", []string{"form button"}},
+		{"my-app >>> form button", []string{"my-app ", " form button"}},
+		{"my-app>>>my-dialog>>>button", []string{"my-app", "my-dialog", "button"}},
+		{`[title=">>>"] >>> p`, []string{`[title=">>>"] `, " p"}},
+		{`[title='a\'>>>'] >>> p`, []string{`[title='a\'>>>'] `, " p"}},
+		{"[title=>>>]", []string{"[title=>>>]"}},
+		{":is(a >>> b) >>> p", []string{":is(a >>> b) ", " p"}},
+	}
+
+	for i, test := range tests {
+		if got := splitShadow(test.sel); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, got)
+		}
+	}
+}
+
+func TestSelectorStrategies(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "sel.html")
+	defer c.Release()
+
+	tests := []struct {
+		sel string
+		by  QueryOption
+		exp int
+	}{
+		{"item", ByClassName, 2},
+		{"2col", ByClassName, 1},
+		{"user[name]", ByName, 2},
+		{"a", ByTagName, 3},
+		{"Home page", ByLinkText, 1},
+		{`About "us"`, ByLinkText, 1},
+		{`It's "contact"`, ByLinkText, 1},
+		{"contact", ByPartialLinkText, 1},
+		{"o", ByPartialLinkText, 3},
+		{"Hello, World!", ByText, 1},
+		{"World", ByText, 1},
+		{"World", ByTextContains, 1},
+		{"^Sub", ByTextRegexp, 2},
+		{"", ByRole("button", "Submit"), 1},
+		{"", ByRole("button", "Close"), 1},
+		{"", ByRole("button", ""), 2},
+	}
+
+	for i, test := range tests {
+		var ids []cdp.NodeID
+		err := c.Run(defaultContext, NodeIDs(test.sel, &ids, test.by, AtLeast(test.exp)))
+		if err != nil {
+			t.Fatalf("test %d got error: %v", i, err)
+		}
+		if len(ids) != test.exp {
+			t.Errorf("test %d expected %d nodes, got: %d", i, test.exp, len(ids))
+		}
+	}
+}
+
+func TestTextAndRoleVisible(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "sel.html")
+	defer c.Release()
+
+	err := c.Run(defaultContext, WaitVisible("Submit", ByText, WithTimeout(500*time.Millisecond)))
+	qerr, ok := err.(*QueryError)
+	if !ok || qerr.Matched != 2 || qerr.Err != ErrNotVisible {
+		t.Fatalf("expected 2 matches with hidden Submit button, got: %v", err)
+	}
+
+	if err = c.Run(defaultContext, WaitVisible("", ByRole("button", "Submit"))); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	var text string
+	err = c.Run(defaultContext, Text("", &text, ByRole("button", "Close"), NodeVisible))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if text != "x" {
+		t.Errorf("expected x, got: %q", text)
+	}
+}
+
+func TestShadowQuery(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "shadow.html")
+	defer c.Release()
+
+	var ids []cdp.NodeID
+	var text, value, urlstr string
+	var ok bool
+	err := c.Run(defaultContext, Tasks{
+		NodeIDs("#app >>> button", &ids, ByQueryAll),
+		Text("my-app >>> my-dialog >>> .message", &text, ByQuery),
+		AttributeValue("#app >>> #name", "value", &value, &ok, ByQuery),
+		ScrollIntoView("#app >>> button", ByQuery),
+		Submit("#app >>> #name", ByQuery),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	// the form is submitted asynchronously
+	ctxt, cancel := context.WithTimeout(defaultContext, 5*time.Second)
+	defer cancel()
+	for {
+		err = c.Run(ctxt, Location(&urlstr))
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+		if strings.HasSuffix(urlstr, "#submitted") {
+			break
+		}
+		select {
+		case <-time.After(DefaultCheckDuration):
+		case <-ctxt.Done():
+			t.Fatalf("expected to be on #submitted, got: %s", urlstr)
+		}
+	}
+	if len(ids) != 1 {
+		t.Errorf("expected 1 node, got: %d", len(ids))
+	}
+	if text != "inner text" {
+		t.Errorf("expected inner text, got: %q", text)
+	}
+	if value != "alice" {
+		t.Errorf("expected alice, got: %q", value)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "table.html")
+	defer c.Release()
+
+	var edited string
+	err := c.Run(defaultContext, Tasks{
+		Click("button", ByQuery, Within("tbody > tr:nth-child(2)", ByQuery)),
+		WaitVisible(`//p[@id="edited" and text()]`),
+		Text("#edited", &edited, ByID),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if edited != "2.1" {
+		t.Errorf("expected 2.1, got: %q", edited)
+	}
+
+	var rows []*cdp.Node
+	var cells []cdp.NodeID
+	err = c.Run(defaultContext, Tasks{
+		Nodes("#footer", &rows, ByID),
+		ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+			return NodeIDs("td", &cells, ByQueryAll, FromNode(rows[0])).Do(ctxt, h)
+		}),
+	})
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if len(cells) != 3 {
+		t.Errorf("expected 3 cells, got: %d", len(cells))
+	}
+
+	err = c.Run(defaultContext, Tasks{
+		Reload(),
+		WaitVisible("#footer", ByID),
+		NodeIDs("td", &cells, ByQueryAll, FromNode(rows[0]), WithTimeout(500*time.Millisecond)),
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalidated") {
+		t.Errorf("expected invalidated node error, got: %v", err)
+	}
+}
+
+func TestInFrameOutOfProcess(t *testing.T) {
+	t.Parallel()
+
+	c, err := pool.Allocate(defaultContext, append(cliOpts, runner.Flag("site-per-process", true))...)
+	if err != nil {
+		t.Fatalf("could not allocate from pool: %v", err)
+	}
+	defer c.Release()
+
+	// serve the iframe from a different site than the parent page
+	child := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer child.Close()
+	_, port, _ := net.SplitHostPort(child.Listener.Addr().String())
+
+	parent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><iframe id="oopif" src="http://localhost:%s/form.html"></iframe></body></html>`, port)
+	}))
+	defer parent.Close()
+
+	err = c.Run(defaultContext, Navigate(parent.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var value string
+	err = c.Run(defaultContext, Tasks{
+		Clear("#keyword", ByID, InFrame("#oopif", ByID)),
+		SendKeys("#keyword", "oopif", ByID, InFrame("#oopif", ByID)),
+		Value("#keyword", &value, ByID, InFrame("#oopif", ByID)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "oopif" {
+		t.Errorf("expected value to be oopif, got: %q", value)
+	}
+}
+
+func TestInFrame(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "frameset.html")
+	defer c.Release()
+
+	var text string
+	err := c.Run(defaultContext, Text("#child1", &text, ByID, InFrame(`frame[src="child1.html"]`, ByQuery)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "child one"; !strings.Contains(text, exp) {
+		t.Errorf("expected text to contain %q, got: %q", exp, text)
+	}
+
+	err = c.Run(defaultContext, WaitNotPresent("#child1", ByQuery, InFrame(`frame[src="child2.html"]`, ByQuery)))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInFrameInput(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "iframe.html")
+	defer c.Release()
+
+	var value string
+	err := c.Run(defaultContext, Tasks{
+		Clear("#keyword", ByID, InFrame("#form-frame", ByID)),
+		Click("#keyword", ByID, InFrame("#form-frame", ByID)),
+		SendKeys("#keyword", "frame", ByID, InFrame("#form-frame", ByID)),
+		Evaluate(`document.getElementById('form-frame').contentDocument.getElementById('keyword').value`, &value),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "frame" {
+		t.Errorf("expected value to be frame, got: %q", value)
+	}
+}
+
+func TestInFrameValue(t *testing.T) {
+	t.Parallel()
+
+	c := testAllocate(t, "iframe.html")
+	defer c.Release()
+
+	var value, frameValue string
+	err := c.Run(defaultContext, Tasks{
+		SetValue("#keyword", "frame", ByID, InFrame("#form-frame", ByID)),
+		Value("#keyword", &value, ByID, InFrame("#form-frame", ByID)),
+		Evaluate(`document.getElementById('form-frame').contentDocument.getElementById('keyword').value`, &frameValue),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "frame" {
+		t.Errorf("expected value to be frame, got: %q", value)
+	}
+	if frameValue != "frame" {
+		t.Errorf("expected frame value to be frame, got: %q", frameValue)
+	}
+}
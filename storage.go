@@ -0,0 +1,404 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/indexeddb"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// StorageState is a snapshot of the browser's cookies, and of the
+// localStorage, sessionStorage, and IndexedDB databases of a set of origins.
+//
+// A storage state can be encoded as JSON, and restored into a fresh target or
+// browser profile with RestoreStorageState.
+type StorageState struct {
+	Cookies []*network.Cookie `json:"cookies"`
+	Origins []*OriginStorage  `json:"origins"`
+}
+
+// OriginStorage is the storage of an origin.
+type OriginStorage struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+	IndexedDB      []*IndexedDB      `json:"indexedDB,omitempty"`
+}
+
+// IndexedDB is an IndexedDB database.
+type IndexedDB struct {
+	Name    string            `json:"name"`
+	Version float64           `json:"version"`
+	Stores  []*IndexedDBStore `json:"stores"`
+}
+
+// IndexedDBStore is an IndexedDB object store and its records.
+type IndexedDBStore struct {
+	Name          string             `json:"name"`
+	KeyPath       json.RawMessage    `json:"keyPath,omitempty"`
+	AutoIncrement bool               `json:"autoIncrement,omitempty"`
+	Indexes       []*IndexedDBIndex  `json:"indexes,omitempty"`
+	Records       []*IndexedDBRecord `json:"records"`
+}
+
+// IndexedDBIndex is an IndexedDB object store index.
+type IndexedDBIndex struct {
+	Name       string          `json:"name"`
+	KeyPath    json.RawMessage `json:"keyPath"`
+	Unique     bool            `json:"unique,omitempty"`
+	MultiEntry bool            `json:"multiEntry,omitempty"`
+}
+
+// IndexedDBRecord is a JSON-encoded IndexedDB object store record.
+type IndexedDBRecord struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// indexedDBPageSize is the number of records retrieved per IndexedDB data
+// request.
+const indexedDBPageSize = 100
+
+// SaveStorageState is an action that saves the browser's cookies, and the
+// storage of the specified origins (ie, "https://example.com") to state. When
+// no origins are specified, the storage of the origins of the current page's
+// frames is saved.
+func SaveStorageState(state *StorageState, origins ...string) Action {
+	if state == nil {
+		panic("state cannot be nil")
+	}
+
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		if len(origins) == 0 {
+			origins = th.frameOrigins()
+		}
+
+		for _, a := range []Action{domstorage.Enable(), indexeddb.Enable()} {
+			if err := a.Do(ctxt, th); err != nil {
+				return err
+			}
+		}
+
+		cookies, err := network.GetAllCookies().Do(ctxt, th)
+		if err != nil {
+			return err
+		}
+
+		s := &StorageState{Cookies: cookies}
+		for _, origin := range origins {
+			o, err := saveOriginStorage(ctxt, th, origin)
+			if err != nil {
+				return fmt.Errorf("could not save storage of %s: %v", origin, err)
+			}
+			s.Origins = append(s.Origins, o)
+		}
+
+		*state = *s
+		return nil
+	})
+}
+
+// frameOrigins returns the sorted, unique http(s) origins of the handler's
+// frames.
+func (h *TargetHandler) frameOrigins() []string {
+	h.RLock()
+	defer h.RUnlock()
+
+	m := make(map[string]bool)
+	for _, f := range h.frames {
+		if strings.HasPrefix(f.SecurityOrigin, "http://") || strings.HasPrefix(f.SecurityOrigin, "https://") {
+			m[f.SecurityOrigin] = true
+		}
+	}
+
+	origins := make([]string, 0, len(m))
+	for origin := range m {
+		origins = append(origins, origin)
+	}
+	sort.Strings(origins)
+
+	return origins
+}
+
+// saveOriginStorage retrieves the storage of the origin.
+func saveOriginStorage(ctxt context.Context, h cdp.Executor, origin string) (*OriginStorage, error) {
+	o := &OriginStorage{Origin: origin}
+
+	var err error
+	o.LocalStorage, err = domStorageItems(ctxt, h, origin, true)
+	if err != nil {
+		return nil, err
+	}
+	o.SessionStorage, err = domStorageItems(ctxt, h, origin, false)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := indexeddb.RequestDatabaseNames(origin).Do(ctxt, h)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		db, err := saveIndexedDB(ctxt, h, origin, name)
+		if err != nil {
+			return nil, err
+		}
+		o.IndexedDB = append(o.IndexedDB, db)
+	}
+
+	return o, nil
+}
+
+// domStorageItems retrieves the local or session storage items of the
+// origin.
+func domStorageItems(ctxt context.Context, h cdp.Executor, origin string, local bool) (map[string]string, error) {
+	items, err := domstorage.GetDOMStorageItems(&domstorage.StorageID{
+		SecurityOrigin: origin,
+		IsLocalStorage: local,
+	}).Do(ctxt, h)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	m := make(map[string]string, len(items))
+	for _, item := range items {
+		if len(item) == 2 {
+			m[item[0]] = item[1]
+		}
+	}
+
+	return m, nil
+}
+
+// saveIndexedDB retrieves the object stores and records of the origin's
+// IndexedDB database.
+func saveIndexedDB(ctxt context.Context, h cdp.Executor, origin, name string) (*IndexedDB, error) {
+	d, err := indexeddb.RequestDatabase(origin, name).Do(ctxt, h)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &IndexedDB{Name: d.Name, Version: d.Version}
+	for _, store := range d.ObjectStores {
+		s := &IndexedDBStore{
+			Name:          store.Name,
+			KeyPath:       keyPathJSON(store.KeyPath),
+			AutoIncrement: store.AutoIncrement,
+			Records:       []*IndexedDBRecord{},
+		}
+
+		for _, i := range store.Indexes {
+			s.Indexes = append(s.Indexes, &IndexedDBIndex{
+				Name:       i.Name,
+				KeyPath:    keyPathJSON(i.KeyPath),
+				Unique:     i.Unique,
+				MultiEntry: i.MultiEntry,
+			})
+		}
+
+		for skip := int64(0); ; skip += indexedDBPageSize {
+			entries, hasMore, err := indexeddb.RequestData(origin, name, store.Name, "", skip, indexedDBPageSize).Do(ctxt, h)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range entries {
+				key, err := remoteObjectValue(ctxt, h, e.PrimaryKey)
+				if err != nil {
+					return nil, err
+				}
+				value, err := remoteObjectValue(ctxt, h, e.Value)
+				if err != nil {
+					return nil, err
+				}
+				s.Records = append(s.Records, &IndexedDBRecord{Key: key, Value: value})
+			}
+
+			if !hasMore || len(entries) == 0 {
+				break
+			}
+		}
+
+		db.Stores = append(db.Stores, s)
+	}
+
+	return db, nil
+}
+
+// keyPathJSON returns the JSON encoding of the key path, or nil for null key
+// paths.
+func keyPathJSON(kp *indexeddb.KeyPath) json.RawMessage {
+	if kp == nil {
+		return nil
+	}
+
+	var v interface{}
+	switch kp.Type {
+	case indexeddb.KeyPathTypeString:
+		v = kp.String
+	case indexeddb.KeyPathTypeArray:
+		v = kp.Array
+	default:
+		return nil
+	}
+
+	buf, _ := json.Marshal(v)
+	return buf
+}
+
+// RestoreStorageState is an action that restores the cookies and origin
+// storage saved in state.
+//
+// To restore the storage of each origin, the current target is navigated to
+// the origin, with the request intercepted and fulfilled with an empty page
+// (ie, no request is sent to the origin's server). The current target is left
+// at about:blank when restoration is complete.
+func RestoreStorageState(state *StorageState) Action {
+	if state == nil {
+		panic("state cannot be nil")
+	}
+
+	return ActionFunc(func(ctxt context.Context, h cdp.Executor) error {
+		th, ok := h.(*TargetHandler)
+		if !ok {
+			return ErrInvalidHandler
+		}
+
+		if len(state.Cookies) != 0 {
+			if err := SetCookies(state.Cookies...).Do(ctxt, th); err != nil {
+				return err
+			}
+		}
+
+		if len(state.Origins) == 0 {
+			return nil
+		}
+
+		if err := domstorage.Enable().Do(ctxt, th); err != nil {
+			return err
+		}
+
+		// serve an empty page for each origin
+		patterns := make([]*fetch.RequestPattern, len(state.Origins))
+		for i, o := range state.Origins {
+			patterns[i] = &fetch.RequestPattern{
+				URLPattern:   o.Origin + "/",
+				ResourceType: network.ResourceTypeDocument,
+			}
+		}
+		stop, err := th.Intercept(ctxt, func(ctxt context.Context, r *InterceptedRequest) error {
+			return r.Fulfill(ctxt, http.StatusOK, http.Header{"Content-Type": {"text/html"}}, nil)
+		}, patterns...)
+		if err != nil {
+			return err
+		}
+		defer stop(ctxt)
+
+		for _, o := range state.Origins {
+			if err := restoreOriginStorage(ctxt, th, o); err != nil {
+				return fmt.Errorf("could not restore storage of %s: %v", o.Origin, err)
+			}
+		}
+
+		return Navigate("about:blank").Do(ctxt, th)
+	})
+}
+
+// waitOriginLoaded waits until the page has loaded a document of the
+// specified origin.
+func waitOriginLoaded(ctxt context.Context, h *TargetHandler, origin string) error {
+	buf, err := json.Marshal(origin)
+	if err != nil {
+		return err
+	}
+	expr := fmt.Sprintf(originLoadedJS, buf)
+
+	for {
+		// evaluation fails while the navigation is in progress
+		var loaded bool
+		if err := Evaluate(expr, &loaded).Do(ctxt, h); err == nil && loaded {
+			return nil
+		}
+
+		select {
+		case <-time.After(DefaultCheckDuration):
+		case <-ctxt.Done():
+			return ctxt.Err()
+		}
+	}
+}
+
+// restoreOriginStorage navigates to the origin, and restores its storage.
+func restoreOriginStorage(ctxt context.Context, h *TargetHandler, o *OriginStorage) error {
+	if err := Navigate(o.Origin+"/").Do(ctxt, h); err != nil {
+		return err
+	}
+
+	// restore the storage in the origin's document, and not in the
+	// navigated from document
+	if err := waitOriginLoaded(ctxt, h, o.Origin); err != nil {
+		return err
+	}
+
+	for _, s := range []struct {
+		items map[string]string
+		local bool
+	}{
+		{o.LocalStorage, true},
+		{o.SessionStorage, false},
+	} {
+		id := &domstorage.StorageID{SecurityOrigin: o.Origin, IsLocalStorage: s.local}
+		for k, v := range s.items {
+			if err := domstorage.SetDOMStorageItem(id, k, v).Do(ctxt, h); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(o.IndexedDB) == 0 {
+		return nil
+	}
+
+	origin, err := json.Marshal(o.Origin)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(o.IndexedDB)
+	if err != nil {
+		return err
+	}
+
+	_, exp, err := runtime.Evaluate(fmt.Sprintf(restoreIndexedDBJS, origin, buf)).
+		WithAwaitPromise(true).
+		WithReturnByValue(true).
+		Do(ctxt, h)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package chromedp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/indexeddb"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestKeyPathJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		kp  *indexeddb.KeyPath
+		exp string
+	}{
+		{nil, ""},
+		{&indexeddb.KeyPath{Type: indexeddb.KeyPathTypeNull}, ""},
+		{&indexeddb.KeyPath{Type: indexeddb.KeyPathTypeString, String: "id"}, `"id"`},
+		{&indexeddb.KeyPath{Type: indexeddb.KeyPathTypeArray, Array: []string{"a", "b"}}, `["a","b"]`},
+	}
+
+	for i, test := range tests {
+		if got := string(keyPathJSON(test.kp)); got != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, got)
+		}
+	}
+}
+
+func TestStorageState(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/save" {
+			fmt.Fprint(w, `<html><body></body></html>`)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		fmt.Fprint(w, `<html><body><div id="saved"></div><script>
+localStorage.setItem('local', 'one');
+sessionStorage.setItem('session', 'two');
+var req = indexedDB.open('app', 1);
+req.onupgradeneeded = function() {
+	req.result.createObjectStore('users', {keyPath: 'id'}).createIndex('name', 'name');
+};
+req.onsuccess = function() {
+	var tx = req.result.transaction('users', 'readwrite');
+	tx.objectStore('users').put({id: 1, name: 'alice'});
+	tx.oncomplete = function() { document.getElementById('saved').textContent = 'saved'; };
+};
+</script></body></html>`)
+	}))
+	defer s.Close()
+
+	c1 := testAllocate(t, "")
+	defer c1.Release()
+
+	var state StorageState
+	err := c1.Run(defaultContext, Tasks{
+		Navigate(s.URL + "/save"),
+		WaitVisible(`//div[@id="saved" and text()]`, BySearch),
+		SaveStorageState(&state),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Origins) != 1 {
+		t.Fatalf("expected 1 origin, got: %d", len(state.Origins))
+	}
+	o := state.Origins[0]
+	if o.LocalStorage["local"] != "one" || o.SessionStorage["session"] != "two" {
+		t.Errorf("expected local and session storage, got: %v %v", o.LocalStorage, o.SessionStorage)
+	}
+	if len(o.IndexedDB) != 1 || len(o.IndexedDB[0].Stores) != 1 || len(o.IndexedDB[0].Stores[0].Records) != 1 {
+		t.Fatalf("expected 1 IndexedDB record, got: %v", o.IndexedDB)
+	}
+
+	c2 := testAllocate(t, "")
+	defer c2.Release()
+
+	var local, session, name string
+	var cookies []*network.Cookie
+	err = c2.Run(defaultContext, Tasks{
+		RestoreStorageState(&state),
+		Navigate(s.URL),
+		Cookies(&cookies),
+		Evaluate(`localStorage.getItem('local')`, &local),
+		Evaluate(`sessionStorage.getItem('session')`, &session),
+		Evaluate(`new Promise(function(resolve) {
+	indexedDB.open('app').onsuccess = function(e) {
+		e.target.result.transaction('users').objectStore('users').get(1).onsuccess = function(e) {
+			resolve(e.target.result.name);
+		};
+	};
+})`, &name, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local != "one" || session != "two" {
+		t.Errorf("expected restored local and session storage, got: %q %q", local, session)
+	}
+	if len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Errorf("expected restored session cookie, got: %v", cookies)
+	}
+	if name != "alice" {
+		t.Errorf("expected restored IndexedDB record alice, got: %q", name)
+	}
+}